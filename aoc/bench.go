@@ -0,0 +1,41 @@
+package aoc
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// Benchmarks a solver on one input. The file is read once, before the
+// timer starts, and the answers are thrown away. Besides the usual ns/op
+// this reports how that splits into parse-ns/op and solve-ns/op.
+func Benchmark(b *testing.B, day int, part int, path string, solve func(run *Run)) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.Skip("No input to benchmark: ", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var parse_time, solve_time time.Duration
+
+	for i := 0; i < b.N; i++ {
+		run := new_run(day, part, NewInput(bytes.NewReader(data), path), io.Discard, "text")
+		run.enter(Parse)
+		solve(run)
+		run.enter("")
+
+		for j, result := range run.Results {
+			if j == 0 {
+				parse_time += result.ParseTime
+			}
+			solve_time += result.SolveTime
+		}
+	}
+
+	b.ReportMetric(float64(parse_time.Nanoseconds())/float64(b.N), "parse-ns/op")
+	b.ReportMetric(float64(solve_time.Nanoseconds())/float64(b.N), "solve-ns/op")
+}
@@ -0,0 +1,181 @@
+// Package aoc holds what every day's solver shares: reading the puzzle
+// input, reporting where it is wrong, and tracing what the solver does.
+package aoc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// A problem with the input, pointing at the offending line and column.
+// Parsers that only see one line fill in Column, Expected and Found; the
+// code reading the file adds File, Line and Text (see Input.Wrap).
+type InputError struct {
+	File string
+	// 1-based. Zero if the error is not about any one line.
+	Line int
+	// 1-based byte offset into Text. Zero if the error is about the whole
+	// line.
+	Column   int
+	Expected string
+	// What was there instead. Empty if that is obvious from Expected.
+	Found string
+	// The whole offending line, for the excerpt.
+	Text string
+}
+
+func (err *InputError) Error() string {
+	location := err.File
+
+	if location == "" {
+		location = "input"
+	}
+
+	if err.Line > 0 {
+		location += fmt.Sprintf(":%d", err.Line)
+
+		if err.Column > 0 {
+			location += fmt.Sprintf(":%d", err.Column)
+		}
+	} else if err.Column > 0 {
+		location += fmt.Sprintf(", column %d", err.Column)
+	}
+
+	message := location + ": expected " + err.Expected
+
+	if err.Found != "" {
+		message += ", found " + err.Found
+	}
+
+	return message
+}
+
+// The offending line with a caret under the column, e.g.
+//
+//	3 | A0QKK 100
+//	  |  ^
+//
+// Empty if the error has no line text. Errors about a flag's value have
+// no line number, and the gutter is left blank.
+func (err *InputError) Excerpt() string {
+	if err.Text == "" && err.Line == 0 {
+		return ""
+	}
+
+	gutter := fmt.Sprintf("%5d | ", err.Line)
+	if err.Line == 0 {
+		gutter = "      | "
+	}
+	excerpt := gutter + err.Text + "\n"
+
+	if err.Column == 0 {
+		return excerpt
+	}
+
+	// Keep tabs so the caret lines up however they are displayed.
+	padding := strings.Builder{}
+	for i, char := range err.Text {
+		if i >= err.Column-1 {
+			break
+		}
+
+		if char == '\t' {
+			padding.WriteByte('\t')
+		} else {
+			padding.WriteByte(' ')
+		}
+	}
+
+	for i := len(err.Text); i < err.Column-1; i++ {
+		padding.WriteByte(' ')
+	}
+
+	return excerpt + strings.Repeat(" ", len(gutter)-2) + "| " + padding.String() + "^\n"
+}
+
+// Shorthand for the error a one-line parser returns; the reader fills in
+// the rest.
+func Expected(column int, expected string, found string) *InputError {
+	return &InputError{Column: column, Expected: expected, Found: found}
+}
+
+// Moves the error right by offset columns, for parsers that were handed a
+// slice of the line rather than all of it. Other errors pass through.
+func Shift(err error, offset int) error {
+	var input_err *InputError
+
+	if errors.As(err, &input_err) && input_err.Column > 0 {
+		input_err.Column += offset
+	}
+
+	return err
+}
+
+// Says which file an error is about, for parsers that read from an
+// io.Reader and so do not know. Other errors pass through.
+func InFile(err error, file string) error {
+	var input_err *InputError
+
+	if errors.As(err, &input_err) {
+		input_err.File = file
+	}
+
+	return err
+}
+
+// Says which line an error from a one-line parser is about, for parsers
+// that are handed several lines at once. Other errors pass through.
+func AtLine(err error, line int, text string) error {
+	var input_err *InputError
+
+	if errors.As(err, &input_err) && input_err.Line == 0 {
+		input_err.Line = line
+		input_err.Text = text
+	}
+
+	return err
+}
+
+// Quotes what was found for an error message, or says the line ended.
+func Found(str string) string {
+	if str == "" {
+		return "end of line"
+	}
+
+	return fmt.Sprintf("%q", str)
+}
+
+// Exits if err is not nil. Input errors are printed with an excerpt of
+// the bad line; anything else is printed as it is.
+func Check(err error) {
+	if err == nil {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+
+	var input_err *InputError
+	if errors.As(err, &input_err) {
+		fmt.Fprint(os.Stderr, input_err.Excerpt())
+	}
+
+	os.Exit(1)
+}
+
+// Checks one row of a character grid: every character must be one of
+// allowed, and the row must be width wide (unless width is negative).
+func GridRow(row string, allowed string, width int) error {
+	for i, char := range row {
+		if !strings.ContainsRune(allowed, char) {
+			return Expected(i+1, "one of "+allowed, Found(string(char)))
+		}
+	}
+
+	if width >= 0 && len(row) != width {
+		return Expected(min(len(row), width)+1, fmt.Sprintf("a row %d wide like the ones before", width), fmt.Sprintf("%d", len(row)))
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package aoc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestInputError(t *testing.T) {
+	tests := []struct {
+		err     *InputError
+		message string
+		excerpt string
+	}{
+		{
+			&InputError{File: "test.txt", Line: 3, Column: 2, Expected: "a card", Found: `"0"`, Text: "A0QKK 100"},
+			`test.txt:3:2: expected a card, found "0"`,
+			"    3 | A0QKK 100\n      |  ^\n",
+		},
+		{
+			&InputError{File: "test.txt", Line: 12, Expected: "a valid line", Text: "junk"},
+			"test.txt:12: expected a valid line",
+			"   12 | junk\n",
+		},
+		{
+			&InputError{Column: 4, Expected: "a digit"},
+			"input, column 4: expected a digit",
+			"",
+		},
+		{
+			// Errors about a flag's value have no line.
+			&InputError{File: "-rule", Column: 3, Expected: "a comparison", Found: `"?"`, Text: "*?2"},
+			`-rule, column 3: expected a comparison, found "?"`,
+			"      | *?2\n      |   ^\n",
+		},
+		{
+			// Tabs are kept so the caret lines up, and a column past the
+			// end points just after it.
+			&InputError{Line: 1, Column: 5, Expected: "more", Text: "\tab"},
+			"input:1:5: expected more",
+			"    1 | \tab\n      | \t   ^\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.message {
+			t.Errorf("Error() = %q, want %q", got, test.message)
+		}
+
+		if got := test.err.Excerpt(); got != test.excerpt {
+			t.Errorf("%s: Excerpt() = %q, want %q", test.message, got, test.excerpt)
+		}
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	err := Expected(3, "a number", Found("x"))
+
+	Shift(err, 10)
+	AtLine(err, 7, "line seven")
+	InFile(err, "real.txt")
+
+	want := InputError{File: "real.txt", Line: 7, Column: 13, Expected: "a number", Found: `"x"`, Text: "line seven"}
+	if *err != want {
+		t.Errorf("after Shift, AtLine and InFile: %+v, want %+v", *err, want)
+	}
+
+	// A line already set is kept, and whole-line errors are not shifted.
+	whole := &InputError{Line: 2, Text: "two", Expected: "something"}
+	AtLine(Shift(whole, 10), 5, "five")
+
+	if whole.Line != 2 || whole.Text != "two" || whole.Column != 0 {
+		t.Errorf("AtLine and Shift changed %+v", *whole)
+	}
+
+	// Other errors pass through, even wrapped.
+	plain := errors.New("plain")
+	if Shift(plain, 1) != plain || AtLine(plain, 1, "") != plain || InFile(plain, "f") != plain {
+		t.Errorf("helpers changed an error that is not an input error")
+	}
+
+	wrapped := fmt.Errorf("while reading: %w", Expected(1, "x", ""))
+	InFile(wrapped, "wrapped.txt")
+
+	var input_err *InputError
+	if !errors.As(wrapped, &input_err) || input_err.File != "wrapped.txt" {
+		t.Errorf("InFile did not reach the wrapped input error")
+	}
+
+	if Found("") != "end of line" || Found("a b") != `"a b"` {
+		t.Errorf("Found quotes wrongly: %s, %s", Found(""), Found("a b"))
+	}
+}
+
+func TestGridRow(t *testing.T) {
+	tests := []struct {
+		row    string
+		width  int
+		column int
+	}{
+		{"#..#", 4, 0},
+		{"#..#", -1, 0},
+		{"#.x#", 4, 3},
+		{"#..", 4, 4},
+		{"#....", 4, 5},
+	}
+
+	for _, test := range tests {
+		err := GridRow(test.row, ".#", test.width)
+
+		if test.column == 0 {
+			if err != nil {
+				t.Errorf("GridRow(%q, %d) failed: %v", test.row, test.width, err)
+			}
+			continue
+		}
+
+		var input_err *InputError
+		if !errors.As(err, &input_err) || input_err.Column != test.column {
+			t.Errorf("GridRow(%q, %d) = %v, want an error at column %d", test.row, test.width, err, test.column)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package aoc
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+)
+
+// Reads the puzzle input a line at a time like a bufio.Scanner, while
+// remembering where it is so errors can point at the right line.
+type Input struct {
+	File    string
+	scanner *bufio.Scanner
+	line    int
+	text    string
+}
+
+// Reads the file named by the first command-line argument, or stdin if
+// there is none, so `go run part1.go real.txt` and `go run part1.go <
+// real.txt` both work. Parses the flags if nothing has yet.
+func Open() *Input {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	if flag.NArg() == 0 {
+		return NewInput(os.Stdin, "<stdin>")
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal("Could not open input: ", err)
+	}
+
+	return NewInput(file, flag.Arg(0))
+}
+
+func NewInput(reader io.Reader, name string) *Input {
+	scanner := bufio.NewScanner(reader)
+	// Some days have very long lines.
+	scanner.Buffer(nil, 1<<24)
+
+	return &Input{File: name, scanner: scanner}
+}
+
+func (input *Input) Scan() bool {
+	if !input.scanner.Scan() {
+		return false
+	}
+
+	input.line++
+	input.text = input.scanner.Text()
+
+	return true
+}
+
+// The line most recently read, as it appears in the file.
+func (input *Input) Text() string {
+	return input.text
+}
+
+// The 1-based number of the line most recently read.
+func (input *Input) Line() int {
+	return input.line
+}
+
+// Any error reading the input (not parsing it).
+func (input *Input) Err() error {
+	return input.scanner.Err()
+}
+
+// Attaches the input's name and the current line to an error from a
+// parser. Parsers that were given several lines say which one themselves,
+// and that is kept. Errors that are not input errors are turned into one
+// about the whole line.
+func (input *Input) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var input_err *InputError
+	if !errors.As(err, &input_err) {
+		return &InputError{File: input.File, Line: input.line, Expected: "a valid line", Found: err.Error(), Text: input.text}
+	}
+
+	input_err.File = input.File
+
+	if input_err.Line == 0 {
+		input_err.Line = input.line
+		input_err.Text = input.text
+	}
+
+	return err
+}
+
+// Reads every remaining line.
+func (input *Input) Lines() ([]string, error) {
+	lines := make([]string, 0)
+
+	for input.Scan() {
+		lines = append(lines, input.Text())
+	}
+
+	return lines, input.Err()
+}
+
+// An error at the given column of the current line (zero for the whole
+// line).
+func (input *Input) At(column int, expected string, found string) error {
+	return input.Wrap(Expected(column, expected, found))
+}
+
+// The error for input that stopped before something it needed.
+func (input *Input) Missing(expected string) error {
+	return &InputError{File: input.File, Line: input.line + 1, Expected: expected, Found: "end of input"}
+}
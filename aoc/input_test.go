@@ -0,0 +1,71 @@
+package aoc
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestInput(t *testing.T) {
+	input := NewInput(strings.NewReader("first\nsecond\r\n\nlast"), "test.txt")
+
+	if !input.Scan() || input.Text() != "first" || input.Line() != 1 {
+		t.Fatalf("first line is %d %q", input.Line(), input.Text())
+	}
+
+	err := input.Wrap(Expected(2, "a number", Found("i")))
+
+	var input_err *InputError
+	if !errors.As(err, &input_err) {
+		t.Fatalf("Wrap = %v, want an input error", err)
+	}
+
+	want := InputError{File: "test.txt", Line: 1, Column: 2, Expected: "a number", Found: `"i"`, Text: "first"}
+	if *input_err != want {
+		t.Errorf("Wrap = %+v, want %+v", *input_err, want)
+	}
+
+	lines, err := input.Lines()
+	if err != nil || !slices.Equal(lines, []string{"second", "", "last"}) {
+		t.Errorf("Lines() = %q, %v", lines, err)
+	}
+
+	if input.Line() != 4 {
+		t.Errorf("Line() after the end = %d, want 4", input.Line())
+	}
+
+	missing := input.Missing("a grid")
+	if !errors.As(missing, &input_err) || input_err.Line != 5 || input_err.Found != "end of input" {
+		t.Errorf("Missing = %v, want an error on line 5", missing)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	input := NewInput(strings.NewReader("one\ntwo\nthree\n"), "test.txt")
+	input.Scan()
+	input.Scan()
+
+	if input.Wrap(nil) != nil {
+		t.Errorf("Wrap(nil) is not nil")
+	}
+
+	// Errors that are not input errors are about the whole line.
+	err := input.Wrap(errors.New("bad thing"))
+
+	var input_err *InputError
+	if !errors.As(err, &input_err) || input_err.Line != 2 || input_err.Column != 0 || input_err.Found != "bad thing" || input_err.Text != "two" {
+		t.Errorf("Wrap(plain error) = %+v", err)
+	}
+
+	// A parser that was given several lines says which one itself.
+	err = input.Wrap(AtLine(Expected(1, "x", ""), 1, "one"))
+	if !errors.As(err, &input_err) || input_err.Line != 1 || input_err.Text != "one" || input_err.File != "test.txt" {
+		t.Errorf("Wrap(error at line 1) = %+v", err)
+	}
+
+	err = input.At(3, "a letter", Found("o"))
+	if !errors.As(err, &input_err) || input_err.Line != 2 || input_err.Column != 3 {
+		t.Errorf("At(3) = %+v", err)
+	}
+}
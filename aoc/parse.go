@@ -0,0 +1,34 @@
+package aoc
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var fields_regex = regexp.MustCompile(`\S+`)
+var numbers_regex = regexp.MustCompile(`^\d+$`)
+
+// Parses the space separated non-negative numbers in str, which starts at
+// the given (0-based) column of the line, so errors point at the right
+// place in it.
+func ParseNumbers(str string, column int) ([]int, error) {
+	fields := fields_regex.FindAllStringIndex(str, -1)
+	integers := make([]int, len(fields))
+
+	for i, field := range fields {
+		v := str[field[0]:field[1]]
+
+		if !numbers_regex.MatchString(v) {
+			return nil, Expected(column+field[0]+1, "a non-negative number", Found(v))
+		}
+
+		integer, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, Expected(column+field[0]+1, "a number that fits in an int", Found(v))
+		}
+
+		integers[i] = integer
+	}
+
+	return integers, nil
+}
@@ -0,0 +1,39 @@
+package aoc
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseNumbers(t *testing.T) {
+	tests := []struct {
+		text    string
+		numbers []int
+		column  int
+	}{
+		{" 41 48 83 86 17 ", []int{41, 48, 83, 86, 17}, 0},
+		{"", []int{}, 0},
+		{"0\t007", []int{0, 7}, 0},
+		{" 41 -48", nil, 15},
+		{" 41 4x8", nil, 15},
+		{" 99999999999999999999", nil, 12},
+	}
+
+	for _, test := range tests {
+		// As if the numbers started at column 11 of the line.
+		numbers, err := ParseNumbers(test.text, 10)
+
+		if test.column == 0 {
+			if err != nil || !slices.Equal(numbers, test.numbers) {
+				t.Errorf("ParseNumbers(%q) = %v, %v, want %v", test.text, numbers, err, test.numbers)
+			}
+			continue
+		}
+
+		input_err := &InputError{}
+		if !errors.As(err, &input_err) || input_err.Column != test.column {
+			t.Errorf("ParseNumbers(%q) = %v, want an error at column %d", test.text, err, test.column)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package aoc
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+)
+
+var cpu_profile = flag.Bool("cpuprofile", false, "write a CPU profile to dayNN-partN-<input>.cpu.pprof")
+var mem_profile = flag.Bool("memprofile", false, "write a heap profile to dayNN-partN-<input>.mem.pprof")
+var exec_trace = flag.Bool("trace", false, "write an execution trace to dayNN-partN-<input>.trace")
+var profile_dir = flag.String("profile-dir", ".", "where -cpuprofile, -memprofile and -trace write their files")
+var show_phases = flag.Bool("phases", false, "print how long parsing, solving and rendering took to stderr")
+
+// Names the profiles after the run, e.g. day05-part2-real.
+func (run *Run) profile_name(kind string) string {
+	input := filepath.Base(run.Input.File)
+	input = strings.TrimSuffix(input, filepath.Ext(input))
+
+	if input == "<stdin>" {
+		input = "stdin"
+	}
+
+	return filepath.Join(*profile_dir, fmt.Sprintf("day%02d-part%d-%s.%s", run.Day, run.Part, input, kind))
+}
+
+func create_profile(path string) *os.File {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatal("Could not create profile: ", err)
+	}
+
+	return file
+}
+
+// Starts whichever profiles were asked for, and returns what stops them
+// and writes them out.
+func (run *Run) start_profiling() func() {
+	stops := make([]func(), 0)
+
+	if *cpu_profile {
+		path := run.profile_name("cpu.pprof")
+		file := create_profile(path)
+
+		if err := pprof.StartCPUProfile(file); err != nil {
+			log.Fatal("Could not start CPU profile: ", err)
+		}
+
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			file.Close()
+			fmt.Fprintln(os.Stderr, "Wrote CPU profile to", path)
+		})
+	}
+
+	if *exec_trace {
+		path := run.profile_name("trace")
+		file := create_profile(path)
+
+		if err := trace.Start(file); err != nil {
+			log.Fatal("Could not start execution trace: ", err)
+		}
+
+		stops = append(stops, func() {
+			trace.Stop()
+			file.Close()
+			fmt.Fprintln(os.Stderr, "Wrote execution trace to", path)
+		})
+	}
+
+	if *mem_profile {
+		path := run.profile_name("mem.pprof")
+
+		stops = append(stops, func() {
+			file := create_profile(path)
+			defer file.Close()
+
+			// Brings the heap statistics up to date.
+			runtime.GC()
+
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				log.Fatal("Could not write heap profile: ", err)
+			}
+			fmt.Fprintln(os.Stderr, "Wrote heap profile to", path)
+		})
+	}
+
+	return func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
+
+// Moves the run into a phase: parse, solve or render. The time is added
+// up per phase, CPU profile samples are labelled phase=<name> (see
+// `go tool pprof -tagfocus`), and an execution trace shows each phase as a
+// region.
+func (run *Run) enter(phase string) string {
+	now := time.Now()
+	previous := run.phase
+
+	if previous != "" {
+		if _, seen := run.phase_times[previous]; !seen {
+			run.phase_order = append(run.phase_order, previous)
+		}
+		run.phase_times[previous] += now.Sub(run.phase_start)
+		run.region.End()
+	}
+
+	run.phase = phase
+	run.phase_start = now
+
+	if phase != "" {
+		ctx := pprof.WithLabels(context.Background(), pprof.Labels("phase", phase))
+		pprof.SetGoroutineLabels(ctx)
+		run.region = trace.StartRegion(ctx, phase)
+	}
+
+	return previous
+}
+
+// Counts the time until the returned function is called as being spent in
+// the given phase, rather than the one the run is in. Usually
+//
+//	done := run.Time(aoc.Render)
+//	draw(...)
+//	done()
+func (run *Run) Time(phase string) func() {
+	previous := run.enter(phase)
+
+	return func() {
+		run.enter(previous)
+	}
+}
+
+func (run *Run) print_phases(output io.Writer) {
+	total := time.Duration(0)
+	for _, phase_time := range run.phase_times {
+		total += phase_time
+	}
+
+	for _, phase := range run.phase_order {
+		phase_time := run.phase_times[phase]
+		share := 0.0
+		if total > 0 {
+			share = float64(phase_time) / float64(total) * 100
+		}
+
+		fmt.Fprintf(output, "%-8s %12s %5.1f%%\n", phase, phase_time.Round(time.Microsecond), share)
+	}
+}
@@ -0,0 +1,147 @@
+package aoc
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var show_progress = flag.Bool("progress", true, "show how far long loops have got on stderr: a bar on a terminal, a line every few seconds otherwise")
+
+const (
+	// How often the bar is redrawn, and how long a loop runs before it
+	// is first drawn, so quick runs show nothing.
+	bar_interval = 200 * time.Millisecond
+	// How often a line is written when stderr is not a terminal.
+	line_interval = 5 * time.Second
+
+	bar_width = 30
+)
+
+// How far a long loop has got. Safe to use from several goroutines at
+// once. The zero value, which Run.Progress gives when progress is off,
+// does nothing.
+type Progress struct {
+	name   string
+	total  int64
+	output io.Writer
+	bar    bool
+
+	done  atomic.Int64
+	start time.Time
+	// When to draw next, in nanoseconds since start.
+	next atomic.Int64
+
+	// Held while drawing. Whoever finds it taken skips drawing rather
+	// than wait.
+	drawing sync.Mutex
+	drawn   bool
+}
+
+func is_terminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Starts reporting progress through total units of work, such as seeds
+// to check. Call Add as they are done, and Done at the end.
+func (run *Run) Progress(name string, total int) *Progress {
+	if run.progress == nil {
+		return &Progress{}
+	}
+
+	progress := &Progress{
+		name:   name,
+		total:  int64(total),
+		output: run.progress,
+		bar:    run.progress_bar,
+		start:  time.Now(),
+	}
+
+	if progress.bar {
+		progress.next.Store(int64(bar_interval))
+	} else {
+		progress.next.Store(int64(line_interval))
+	}
+
+	return progress
+}
+
+// Counts n more units as done, and draws if it is time to. Cheap, but not
+// free: loops that do billions of units call it every million or so.
+func (progress *Progress) Add(n int) {
+	if progress.output == nil {
+		return
+	}
+
+	progress.done.Add(int64(n))
+
+	elapsed := time.Since(progress.start)
+	if int64(elapsed) < progress.next.Load() || !progress.drawing.TryLock() {
+		return
+	}
+	defer progress.drawing.Unlock()
+
+	if progress.bar {
+		progress.next.Store(int64(elapsed + bar_interval))
+	} else {
+		progress.next.Store(int64(elapsed + line_interval))
+	}
+
+	progress.draw(elapsed, false)
+}
+
+// Finishes the bar, if one was drawn, or writes a last line, if any were
+// written. Loops that stop early still call it, to show where they got to.
+func (progress *Progress) Done() {
+	if progress.output == nil {
+		return
+	}
+
+	progress.drawing.Lock()
+	defer progress.drawing.Unlock()
+
+	if progress.drawn {
+		progress.draw(time.Since(progress.start), true)
+	}
+}
+
+func (progress *Progress) draw(elapsed time.Duration, last bool) {
+	done := min(progress.done.Load(), progress.total)
+
+	fraction := 1.0
+	if progress.total > 0 {
+		fraction = float64(done) / float64(progress.total)
+	}
+
+	status := ""
+	switch {
+	case last:
+		status = "took " + elapsed.Round(time.Millisecond).String()
+	case done > 0:
+		left := time.Duration(float64(elapsed) * float64(progress.total-done) / float64(done))
+		status = "ETA " + left.Round(time.Second).String()
+	}
+
+	progress.drawn = true
+
+	if !progress.bar {
+		fmt.Fprintf(progress.output, "progress: %s %d/%d (%.1f%%) %s\n", progress.name, done, progress.total, fraction*100, status)
+		return
+	}
+
+	filled := int(fraction * bar_width)
+	bar := strings.Repeat("#", filled) + strings.Repeat(".", bar_width-filled)
+
+	// \r and clearing to the end of the line redraws it in place.
+	fmt.Fprintf(progress.output, "\r%s [%s] %5.1f%% %s\033[K", progress.name, bar, fraction*100, status)
+
+	if last {
+		fmt.Fprintln(progress.output)
+	}
+}
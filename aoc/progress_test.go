@@ -0,0 +1,65 @@
+package aoc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressOff(t *testing.T) {
+	// What Run.Progress gives when progress is off.
+	progress := (&Run{}).Progress("Checking", 10)
+
+	progress.Add(5)
+	progress.Done()
+}
+
+func TestProgressLines(t *testing.T) {
+	output := strings.Builder{}
+	progress := &Progress{name: "Checking seeds", total: 200, output: &output, start: time.Now()}
+
+	// Due straight away, then not again for line_interval.
+	progress.Add(50)
+	progress.Add(50)
+	progress.Done()
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("wrote %q, want two lines", lines)
+	}
+
+	if !strings.HasPrefix(lines[0], "progress: Checking seeds 50/200 (25.0%) ETA ") {
+		t.Errorf("first line is %q", lines[0])
+	}
+
+	if !strings.HasPrefix(lines[1], "progress: Checking seeds 100/200 (50.0%) took ") {
+		t.Errorf("last line is %q", lines[1])
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	output := strings.Builder{}
+	progress := &Progress{name: "Energising", total: 4, output: &output, bar: true, start: time.Now()}
+
+	// More than the total is shown as all of it.
+	progress.Add(6)
+	progress.Done()
+
+	text := output.String()
+
+	if !strings.HasPrefix(text, "\rEnergising ["+strings.Repeat("#", bar_width)+"] 100.0%") || !strings.HasSuffix(text, "\n") {
+		t.Errorf("drew %q", text)
+	}
+
+	// Nothing is drawn for a loop that finishes before it is due.
+	quiet := strings.Builder{}
+	progress = &Progress{name: "Quick", total: 4, output: &quiet, bar: true, start: time.Now()}
+	progress.next.Store(int64(bar_interval))
+	progress.Add(4)
+	progress.Done()
+
+	if quiet.Len() != 0 {
+		t.Errorf("a quick loop drew %q", quiet.String())
+	}
+}
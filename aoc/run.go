@@ -0,0 +1,341 @@
+package aoc
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/metrics"
+	"runtime/trace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var format = flag.String("format", "text", "how to print answers: text, json (one object per line) or tsv")
+var timeout = flag.Duration("timeout", 0, "stop solving after this long, e.g. 30s, and print the progress so far (default $AOC_TIMEOUT, or no limit)")
+
+// How long a solver that does not check Run.Context gets to stop after
+// timing out before the run is abandoned.
+const timeout_grace = 2 * time.Second
+
+var integer_regex = regexp.MustCompile(`^-?\d+$`)
+
+// What a solver found, and what it cost to find.
+type Result struct {
+	Day   int    `json:"day"`
+	Part  int    `json:"part"`
+	Input string `json:"input"`
+	// What the answer is, e.g. "Total winnings".
+	Name   string `json:"name"`
+	Answer Answer `json:"answer"`
+	// Reading and parsing the input.
+	ParseTime time.Duration `json:"parse_ns"`
+	// Since parsing finished, or since the previous answer.
+	SolveTime time.Duration `json:"solve_ns"`
+	// The most heap in use at once since the run started. It is sampled,
+	// so a short spike can be missed.
+	PeakHeap uint64 `json:"peak_heap_bytes"`
+	// Everything allocated since the run started.
+	AllocBytes uint64 `json:"alloc_bytes"`
+	Allocs     uint64 `json:"allocs"`
+	// The run timed out first, so this is only the progress so far, such
+	// as the best found before stopping.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// An answer as printed. JSON gets it as a number if it is an integer,
+// however big, and as a string otherwise.
+type Answer string
+
+func (answer Answer) MarshalJSON() ([]byte, error) {
+	if integer_regex.MatchString(string(answer)) {
+		return []byte(answer), nil
+	}
+
+	return json.Marshal(string(answer))
+}
+
+var tsv_header = []string{"day", "part", "input", "name", "answer", "parse_ns", "solve_ns", "peak_heap_bytes", "alloc_bytes", "allocs", "partial"}
+
+func (result Result) tsv_fields() []string {
+	return []string{
+		fmt.Sprint(result.Day),
+		fmt.Sprint(result.Part),
+		result.Input,
+		result.Name,
+		string(result.Answer),
+		fmt.Sprint(result.ParseTime.Nanoseconds()),
+		fmt.Sprint(result.SolveTime.Nanoseconds()),
+		fmt.Sprint(result.PeakHeap),
+		fmt.Sprint(result.AllocBytes),
+		fmt.Sprint(result.Allocs),
+		fmt.Sprint(result.Partial),
+	}
+}
+
+// One solver working through one input.
+type Run struct {
+	Day int
+	// Solvers that answer more than one part set this before each Answer.
+	Part  int
+	Input *Input
+
+	Results []Result
+
+	output io.Writer
+	format string
+
+	ctx context.Context
+
+	// Where progress goes, nil if nowhere, and whether it is a terminal.
+	progress     io.Writer
+	progress_bar bool
+
+	start  time.Time
+	parsed time.Time
+	last   time.Time
+
+	// Nil when benchmarking, which measures memory its own way.
+	memory *memory_sampler
+
+	phase       string
+	phase_start time.Time
+	phase_times map[string]time.Duration
+	phase_order []string
+	region      *trace.Region
+}
+
+// Runs a solver on the input named on the command line (see Open) and
+// prints its answers in the format chosen by -format. Parses the flags
+// first, so solvers declare theirs at package level.
+func Main(day int, part int, solve func(run *Run)) {
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	if *format != "text" && *format != "json" && *format != "tsv" {
+		log.Fatal("Unknown format: ", *format)
+	}
+
+	limit := *timeout
+	if limit == 0 && os.Getenv("AOC_TIMEOUT") != "" {
+		var err error
+		limit, err = time.ParseDuration(os.Getenv("AOC_TIMEOUT"))
+		if err != nil {
+			log.Fatal("Bad AOC_TIMEOUT: ", err)
+		}
+	}
+
+	run := new_run(day, part, Open(), os.Stdout, *format)
+	run.memory = start_memory_sampler()
+	defer run.memory.stop()
+
+	if limit > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), limit)
+		defer cancel()
+		run.ctx = ctx
+	}
+
+	if *show_progress {
+		run.progress = os.Stderr
+		run.progress_bar = is_terminal(os.Stderr)
+	}
+
+	stop_profiling := run.start_profiling()
+
+	finished := make(chan struct{})
+	go run.watch(finished, limit)
+
+	run.enter(Parse)
+	solve(run)
+	run.enter("")
+	close(finished)
+
+	stop_profiling()
+
+	if *show_phases {
+		run.print_phases(os.Stderr)
+	}
+
+	if run.ctx.Err() != nil {
+		log.Fatal("Timed out after ", limit)
+	}
+}
+
+// Gives up on a solver that keeps going long after timing out, which
+// means it never looks at Run.Context.
+func (run *Run) watch(finished chan struct{}, limit time.Duration) {
+	select {
+	case <-finished:
+		return
+	case <-run.ctx.Done():
+	}
+
+	select {
+	case <-finished:
+	case <-time.After(timeout_grace):
+		log.Fatal("Timed out after ", limit, ", and the solver did not stop")
+	}
+}
+
+func new_run(day int, part int, input *Input, output io.Writer, format string) *Run {
+	now := time.Now()
+
+	return &Run{
+		Day:    day,
+		Part:   part,
+		Input:  input,
+		output: output,
+		format: format,
+		ctx:    context.Background(),
+		start:  now,
+		last:   now,
+
+		phase_times: make(map[string]time.Duration),
+	}
+}
+
+// Marks the end of parsing. Everything before is parse time and
+// everything after is solve time.
+func (run *Run) Parsed() {
+	run.parsed = time.Now()
+	run.last = run.parsed
+	run.enter(Solve)
+}
+
+// Done when the run times out (see -timeout). Solvers that can take a
+// long time check it in their main loops, and when it is done stop and
+// answer with what they have so far.
+func (run *Run) Context() context.Context {
+	return run.ctx
+}
+
+// Records and prints an answer. Anything fmt can print will do, such as
+// an int or a *big.Int. Answers given after the run timed out are marked
+// partial.
+func (run *Run) Answer(name string, answer any) {
+	now := time.Now()
+
+	result := Result{
+		Day:       run.Day,
+		Part:      run.Part,
+		Input:     run.Input.File,
+		Name:      name,
+		Answer:    Answer(fmt.Sprint(answer)),
+		SolveTime: now.Sub(run.last),
+		Partial:   run.ctx.Err() != nil,
+	}
+
+	if run.memory != nil {
+		result.PeakHeap, result.AllocBytes, result.Allocs = run.memory.usage()
+	}
+
+	if !run.parsed.IsZero() {
+		result.ParseTime = run.parsed.Sub(run.start)
+	}
+
+	run.last = now
+	run.Results = append(run.Results, result)
+
+	run.print(result)
+}
+
+func (run *Run) print(result Result) {
+	switch run.format {
+	case "json":
+		line, err := json.Marshal(result)
+		if err != nil {
+			log.Fatal("Could not encode result: ", err)
+		}
+		fmt.Fprintln(run.output, string(line))
+	case "tsv":
+		if len(run.Results) == 1 {
+			fmt.Fprintln(run.output, strings.Join(tsv_header, "\t"))
+		}
+		fmt.Fprintln(run.output, strings.Join(result.tsv_fields(), "\t"))
+	default:
+		if result.Partial {
+			fmt.Fprintf(run.output, "%s: %s (partial: timed out)\n", result.Name, result.Answer)
+			return
+		}
+		fmt.Fprintf(run.output, "%s: %s\n", result.Name, result.Answer)
+	}
+}
+
+// Watches the heap in the background. The runtime does not keep a
+// high-water mark, so this takes one every few milliseconds.
+type memory_sampler struct {
+	peak        atomic.Uint64
+	base_bytes  uint64
+	base_allocs uint64
+
+	done chan struct{}
+	once sync.Once
+}
+
+const memory_sample_interval = 5 * time.Millisecond
+
+// Cheap enough to call every few milliseconds, unlike
+// runtime.ReadMemStats, but only approximate.
+func sample_heap() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+
+	return sample[0].Value.Uint64()
+}
+
+func start_memory_sampler() *memory_sampler {
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+
+	sampler := &memory_sampler{base_bytes: stats.TotalAlloc, base_allocs: stats.Mallocs, done: make(chan struct{})}
+	sampler.peak.Store(stats.HeapAlloc)
+
+	go func() {
+		ticker := time.NewTicker(memory_sample_interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sampler.done:
+				return
+			case <-ticker.C:
+				sampler.record(sample_heap())
+			}
+		}
+	}()
+
+	return sampler
+}
+
+func (sampler *memory_sampler) record(heap uint64) {
+	for {
+		peak := sampler.peak.Load()
+		if heap <= peak || sampler.peak.CompareAndSwap(peak, heap) {
+			return
+		}
+	}
+}
+
+// The peak heap so far, and what has been allocated since the sampler
+// started.
+func (sampler *memory_sampler) usage() (uint64, uint64, uint64) {
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+	sampler.record(stats.HeapAlloc)
+
+	return sampler.peak.Load(), stats.TotalAlloc - sampler.base_bytes, stats.Mallocs - sampler.base_allocs
+}
+
+func (sampler *memory_sampler) stop() {
+	sampler.once.Do(func() {
+		close(sampler.done)
+	})
+}
@@ -0,0 +1,95 @@
+package aoc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestAnswer(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	tests := []struct {
+		format string
+		name   string
+		answer any
+		want   string
+	}{
+		{"text", "Total", 6440, "Total: 6440\n"},
+		{"text", "Path", "a -> b", "Path: a -> b\n"},
+		{"json", "Total", huge, `"answer":123456789012345678901234567890`},
+		{"json", "Path", "up, then left", `"answer":"up, then left"`},
+		{"json", "Negative", -7, `"answer":-7`},
+		{"tsv", "Total", 6440, "day\tpart\tinput\tname\tanswer\t"},
+	}
+
+	for _, test := range tests {
+		output := strings.Builder{}
+		run := new_run(7, 1, NewInput(strings.NewReader(""), "test.txt"), &output, test.format)
+
+		run.Parsed()
+		run.Answer(test.name, test.answer)
+
+		if !strings.Contains(output.String(), test.want) {
+			t.Errorf("%s answer %v printed %q, want it to contain %q", test.format, test.answer, output.String(), test.want)
+		}
+	}
+}
+
+func TestAnswerJSON(t *testing.T) {
+	output := strings.Builder{}
+	run := new_run(17, 2, NewInput(strings.NewReader(""), "real.txt"), &output, "json")
+
+	run.Answer("Total cost", 94)
+	run.Answer("Path", "RRD")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("printed %d lines, want 2", len(lines))
+	}
+
+	result := map[string]any{}
+	if err := json.Unmarshal([]byte(lines[0]), &result); err != nil {
+		t.Fatalf("could not read back %q: %v", lines[0], err)
+	}
+
+	if result["day"] != 17.0 || result["part"] != 2.0 || result["input"] != "real.txt" || result["name"] != "Total cost" || result["answer"] != 94.0 {
+		t.Errorf("printed %v", result)
+	}
+
+	if _, partial := result["partial"]; partial {
+		t.Errorf("a finished answer says partial: %v", result)
+	}
+
+	if len(run.Results) != 2 || run.Results[1].Answer != "RRD" {
+		t.Errorf("Results = %+v", run.Results)
+	}
+}
+
+func TestPartialAnswer(t *testing.T) {
+	formats := []struct {
+		format string
+		want   string
+	}{
+		{"text", "Total cost: 102 (partial: timed out)\n"},
+		{"json", `"partial":true`},
+		{"tsv", "\ttrue\n"},
+	}
+
+	for _, test := range formats {
+		output := strings.Builder{}
+		run := new_run(17, 1, NewInput(strings.NewReader(""), "real.txt"), &output, test.format)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		run.ctx = ctx
+
+		run.Answer("Total cost", 102)
+
+		if !run.Results[0].Partial || !strings.Contains(output.String(), test.want) {
+			t.Errorf("%s: printed %q, want it to contain %q", test.format, output.String(), test.want)
+		}
+	}
+}
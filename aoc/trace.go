@@ -0,0 +1,214 @@
+package aoc
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// How much a category of tracing says. Each level includes the ones
+// before it.
+type Level int
+
+const (
+	Off Level = iota
+	// A few lines per run: summaries and intermediate results.
+	Info
+	// A few lines per input line or search step.
+	Debug
+)
+
+var level_names = map[string]Level{
+	"off":   Off,
+	"info":  Info,
+	"debug": Debug,
+}
+
+// The subsystems that trace. Solvers use these so one setting reaches
+// the same kind of output on every day.
+const (
+	Parse  = "parse"
+	Solve  = "solve"
+	Search = "search"
+	Render = "render"
+)
+
+var log_spec = flag.String("log", "", "tracing to show on stderr: a level (info, debug) or category=level pairs such as parse=debug,search=info (default $AOC_LOG)")
+var log_file = flag.String("log-file", "", "write tracing to this file instead of stderr (default $AOC_LOG_FILE)")
+
+type trace_config struct {
+	levels   map[string]Level
+	fallback Level
+	output   io.Writer
+}
+
+// Serialises writes, so lines from different goroutines do not mix.
+var trace_lock sync.Mutex
+var config atomic.Pointer[trace_config]
+var config_lock sync.Mutex
+
+// Parses a spec like "debug" or "parse=debug,render=info".
+func parse_log_spec(spec string) (map[string]Level, Level, error) {
+	levels := make(map[string]Level)
+	fallback := Off
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+
+		if part == "" {
+			continue
+		}
+
+		category, name, found := strings.Cut(part, "=")
+		if !found {
+			category, name = "", part
+		}
+
+		level, ok := level_names[name]
+		if !ok {
+			return nil, Off, fmt.Errorf("unknown log level %q in %q", name, spec)
+		}
+
+		if category == "" || category == "all" {
+			fallback = level
+		} else {
+			levels[category] = level
+		}
+	}
+
+	return levels, fallback, nil
+}
+
+// Reads the settings the first time they are needed after the flags have
+// been parsed. Before then only the environment counts, and tracing goes
+// to stderr.
+func current_config() *trace_config {
+	if current := config.Load(); current != nil {
+		return current
+	}
+
+	config_lock.Lock()
+	defer config_lock.Unlock()
+
+	if current := config.Load(); current != nil {
+		return current
+	}
+
+	spec, path := os.Getenv("AOC_LOG"), os.Getenv("AOC_LOG_FILE")
+
+	if flag.Parsed() {
+		if *log_spec != "" {
+			spec = *log_spec
+		}
+		if *log_file != "" {
+			path = *log_file
+		}
+	}
+
+	levels, fallback, err := parse_log_spec(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	next := &trace_config{levels: levels, fallback: fallback, output: os.Stderr}
+
+	if !flag.Parsed() {
+		return next
+	}
+
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			log.Fatal("Could not create log file: ", err)
+		}
+		next.output = file
+	}
+
+	config.Store(next)
+
+	return next
+}
+
+// Writes one category's tracing. The zero value traces nothing.
+type Tracer struct {
+	category string
+}
+
+func Trace(category string) Tracer {
+	return Tracer{category}
+}
+
+func (tracer Tracer) Enabled(level Level) bool {
+	if tracer.category == "" || level == Off {
+		return false
+	}
+
+	config := current_config()
+
+	if category_level, ok := config.levels[tracer.category]; ok {
+		return level <= category_level
+	}
+
+	return level <= config.fallback
+}
+
+func (tracer Tracer) emit(message string) {
+	trace_lock.Lock()
+	defer trace_lock.Unlock()
+
+	io.WriteString(current_config().output, tracer.category+": "+message)
+}
+
+// Like fmt.Println, at the info level. Like the others below, it only
+// formats its arguments if the level is on, but they are still built, so
+// loops that trace every step check Enabled once beforehand.
+func (tracer Tracer) Info(args ...any) {
+	if tracer.Enabled(Info) {
+		tracer.emit(fmt.Sprintln(args...))
+	}
+}
+
+// Like fmt.Println, at the debug level.
+func (tracer Tracer) Debug(args ...any) {
+	if tracer.Enabled(Debug) {
+		tracer.emit(fmt.Sprintln(args...))
+	}
+}
+
+func (tracer Tracer) Infof(format string, args ...any) {
+	if tracer.Enabled(Info) {
+		tracer.emit(fmt.Sprintf(format+"\n", args...))
+	}
+}
+
+func (tracer Tracer) Debugf(format string, args ...any) {
+	if tracer.Enabled(Debug) {
+		tracer.emit(fmt.Sprintf(format+"\n", args...))
+	}
+}
+
+// Where to draw multi-line output such as a rendered grid, or io.Discard
+// if the level is off. Check Enabled first if drawing is expensive.
+func (tracer Tracer) Writer(level Level) io.Writer {
+	if !tracer.Enabled(level) {
+		return io.Discard
+	}
+
+	return locked_writer{current_config().output}
+}
+
+type locked_writer struct {
+	output io.Writer
+}
+
+func (writer locked_writer) Write(data []byte) (int, error) {
+	trace_lock.Lock()
+	defer trace_lock.Unlock()
+
+	return writer.output.Write(data)
+}
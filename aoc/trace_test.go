@@ -0,0 +1,82 @@
+package aoc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLogSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		levels   map[string]Level
+		fallback Level
+		wrong    bool
+	}{
+		{"", map[string]Level{}, Off, false},
+		{"debug", map[string]Level{}, Debug, false},
+		{"parse=debug,search=info", map[string]Level{Parse: Debug, Search: Info}, Off, false},
+		{" all=info , render=off ", map[string]Level{Render: Off}, Info, false},
+		{"parse=loud", nil, Off, true},
+	}
+
+	for _, test := range tests {
+		levels, fallback, err := parse_log_spec(test.spec)
+
+		if test.wrong {
+			if err == nil {
+				t.Errorf("parse_log_spec(%q) succeeded, want an error", test.spec)
+			}
+			continue
+		}
+
+		if err != nil || fallback != test.fallback || len(levels) != len(test.levels) {
+			t.Errorf("parse_log_spec(%q) = %v, %v, %v, want %v, %v", test.spec, levels, fallback, err, test.levels, test.fallback)
+			continue
+		}
+
+		for category, level := range test.levels {
+			if levels[category] != level {
+				t.Errorf("parse_log_spec(%q): %s is %v, want %v", test.spec, category, levels[category], level)
+			}
+		}
+	}
+}
+
+// Counts how often it is formatted.
+type counted struct {
+	count *int
+}
+
+func (c counted) String() string {
+	*c.count++
+	return "counted"
+}
+
+func TestTracer(t *testing.T) {
+	output := strings.Builder{}
+
+	previous := config.Load()
+	config.Store(&trace_config{levels: map[string]Level{Parse: Debug, Search: Off}, fallback: Info, output: &output})
+	defer config.Store(previous)
+
+	formatted := 0
+	arg := counted{&formatted}
+
+	Trace(Parse).Debug("parsed", arg)
+	Trace(Solve).Info("solved", arg)
+	Trace(Solve).Debugf("step %v", arg)
+	Trace(Search).Infof("searched %v", arg)
+	Tracer{}.Info("nowhere", arg)
+	Trace(Render).Writer(Debug).Write([]byte("not drawn\n"))
+	Trace(Render).Writer(Info).Write([]byte("drawn\n"))
+
+	want := "parse: parsed counted\nsolve: solved counted\ndrawn\n"
+	if output.String() != want {
+		t.Errorf("traced %q, want %q", output.String(), want)
+	}
+
+	// Only the two lines written formatted their arguments.
+	if formatted != 2 {
+		t.Errorf("arguments were formatted %d times, want 2", formatted)
+	}
+}
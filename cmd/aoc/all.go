@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// The answers each day's solvers should give, one per line:
+//
+//	part1.go real.txt Total winnings: 253603890
+//
+// or, for an input the solver should reject,
+//
+//	part2.go test.txt error
+const expected_file = "expected.txt"
+
+// The date and time log.Fatal starts with.
+var log_prefix_regex = regexp.MustCompile(`^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d `)
+
+// One solver on one input.
+type job struct {
+	solver solver
+	input  string
+	// Flags for the solver, such as -unfold 1.
+	args []string
+}
+
+// An answer as read back from a solver's -format json output.
+type answer_line struct {
+	Name    string          `json:"name"`
+	Answer  json.RawMessage `json:"answer"`
+	Partial bool            `json:"partial"`
+}
+
+func (line answer_line) answer() string {
+	text := ""
+	if json.Unmarshal(line.Answer, &text) == nil {
+		return text
+	}
+
+	return string(line.Answer)
+}
+
+type outcome struct {
+	job     job
+	answers []answer_line
+	// Why the run failed, if it did: the first line it wrote to stderr,
+	// or the panic.
+	err       string
+	panicked  bool
+	timed_out bool
+	took      time.Duration
+}
+
+func all(args []string) {
+	flags := flag.NewFlagSet("all", flag.ExitOnError)
+	workers := flags.Int("workers", runtime.NumCPU(), "how many solvers to run at once")
+	timeout := flags.Duration("timeout", time.Minute, "passed to each solver's -timeout")
+	day_list := flags.String("days", "", "only run these days, e.g. 5,12")
+	update := flags.Bool("update", false, "write this run's answers to each day's "+expected_file)
+	flags.Parse(args)
+
+	days, err := parse_days(*day_list)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	root := find_root()
+
+	solvers := make([]solver, 0)
+	for _, solver := range find_solvers(root) {
+		if days == nil || days[solver.day] {
+			solvers = append(solvers, solver)
+		}
+	}
+
+	bin, err := os.MkdirTemp("", "aoc-all-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+
+	binaries := build_solvers(root, bin, solvers, *workers)
+
+	jobs := make([]job, 0)
+	for _, solver := range solvers {
+		for _, input := range find_inputs(filepath.Join(root, solver.dir)) {
+			jobs = append(jobs, job{solver: solver, input: input})
+		}
+	}
+
+	outcomes := make([]outcome, len(jobs))
+	next := make(chan int)
+	wait := sync.WaitGroup{}
+
+	for w := 0; w < max(*workers, 1); w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range next {
+				outcomes[i] = run_job(root, binaries[jobs[i].solver], jobs[i], *timeout)
+			}
+		}()
+	}
+
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wait.Wait()
+
+	expected := make(map[string]map[string]string)
+	for _, solver := range solvers {
+		if expected[solver.dir] == nil {
+			expected[solver.dir] = load_expected(filepath.Join(root, solver.dir, expected_file))
+		}
+	}
+
+	failed := print_summary(outcomes, expected)
+
+	if *update {
+		save_expected(root, outcomes)
+		return
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// Builds each solver once, so the runs do not each compile it. Solvers
+// that do not build are left out of the map, and their runs fail.
+func build_solvers(root string, bin string, solvers []solver, workers int) map[solver]string {
+	binaries := make(map[solver]string)
+	lock := sync.Mutex{}
+
+	next := make(chan solver)
+	wait := sync.WaitGroup{}
+
+	for w := 0; w < max(workers, 1); w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for solver := range next {
+				path := filepath.Join(bin, fmt.Sprintf("day%02d-part%d", solver.day, solver.part))
+
+				command := exec.Command("go", "build", "-o", path, solver.file)
+				command.Dir = filepath.Join(root, solver.dir)
+				output, err := command.CombinedOutput()
+
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s does not build: %v\n%s", solver, err, output)
+					continue
+				}
+
+				lock.Lock()
+				binaries[solver] = path
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, solver := range solvers {
+		next <- solver
+	}
+	close(next)
+	wait.Wait()
+
+	return binaries
+}
+
+// real.txt and every test*.txt in the directory.
+func find_inputs(dir string) []string {
+	inputs := make([]string, 0)
+
+	if _, err := os.Stat(filepath.Join(dir, "real.txt")); err == nil {
+		inputs = append(inputs, "real.txt")
+	}
+
+	tests, _ := filepath.Glob(filepath.Join(dir, "test*.txt"))
+	for _, test := range tests {
+		inputs = append(inputs, filepath.Base(test))
+	}
+
+	return inputs
+}
+
+// Runs one solver on one input in its own process, so a panic or a
+// log.Fatal only ends that run.
+func run_job(root string, binary string, job job, timeout time.Duration) outcome {
+	result := outcome{job: job}
+
+	if binary == "" {
+		result.err = "does not build"
+		return result
+	}
+
+	args := append(slices.Clone(job.args), "-format", "json", "-progress=false", "-timeout", timeout.String(), job.input)
+
+	command := exec.Command(binary, args...)
+	command.Dir = filepath.Join(root, job.solver.dir)
+
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+
+	begin := time.Now()
+	err := command.Run()
+	result.took = time.Since(begin)
+
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		line := answer_line{}
+		if json.Unmarshal(scanner.Bytes(), &line) == nil && line.Name != "" {
+			result.answers = append(result.answers, line)
+		}
+	}
+
+	if err != nil {
+		messages := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		result.err = log_prefix_regex.ReplaceAllString(messages[0], "")
+		result.timed_out = strings.Contains(stderr.String(), "Timed out after")
+
+		for _, message := range messages {
+			if strings.HasPrefix(message, "panic: ") {
+				result.err = message
+				result.panicked = true
+				break
+			}
+		}
+
+		if result.err == "" {
+			result.err = err.Error()
+		}
+	}
+
+	return result
+}
+
+func expected_key(file string, input string, name string) string {
+	return file + " " + input + " " + name
+}
+
+// The expected answers in a day's file, by expected_key. An input the
+// solver should reject has the name "error".
+func load_expected(path string) map[string]string {
+	expected := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return expected
+	}
+	if err != nil {
+		log.Fatal("Could not read expected answers: ", err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 3 {
+			log.Fatalf("%s:%d: expected \"<file> <input> <name>: <answer>\" or \"<file> <input> error\"", path, i+1)
+		}
+
+		if fields[2] == "error" {
+			expected[expected_key(fields[0], fields[1], "error")] = ""
+			continue
+		}
+
+		separator := strings.LastIndex(fields[2], ": ")
+		if separator < 0 {
+			log.Fatalf("%s:%d: expected \"<name>: <answer>\" after the input", path, i+1)
+		}
+
+		expected[expected_key(fields[0], fields[1], fields[2][:separator])] = fields[2][separator+2:]
+	}
+
+	return expected
+}
+
+// Updates each day's expected answers with the runs that finished. Runs
+// that timed out or panicked keep what was expected before.
+func save_expected(root string, outcomes []outcome) {
+	lines := make(map[string][]string)
+	finished := make(map[string]bool)
+
+	for _, outcome := range outcomes {
+		dir := outcome.job.solver.dir
+		prefix := outcome.job.solver.file + " " + outcome.job.input + " "
+
+		if outcome.panicked || outcome.timed_out {
+			continue
+		}
+
+		finished[dir+"/"+prefix] = true
+
+		if outcome.err != "" && len(outcome.answers) == 0 {
+			lines[dir] = append(lines[dir], prefix+"error")
+			continue
+		}
+
+		for _, answer := range outcome.answers {
+			lines[dir] = append(lines[dir], fmt.Sprintf("%s%s: %s", prefix, answer.Name, answer.answer()))
+		}
+	}
+
+	dirs := make([]string, 0, len(lines))
+	for dir := range lines {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		day_lines := lines[dir]
+		path := filepath.Join(root, dir, expected_file)
+
+		old, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatal("Could not read expected answers: ", err)
+		}
+
+		for _, line := range strings.Split(string(old), "\n") {
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) == 3 && !finished[dir+"/"+fields[0]+" "+fields[1]+" "] {
+				day_lines = append(day_lines, line)
+			}
+		}
+
+		sort.Strings(day_lines)
+
+		if err := os.WriteFile(path, []byte(strings.Join(day_lines, "\n")+"\n"), 0644); err != nil {
+			log.Fatal("Could not write expected answers: ", err)
+		}
+
+		fmt.Fprintln(os.Stderr, "Wrote", path)
+	}
+}
+
+// Prints a row per answer, or per run that gave none, and says whether
+// anything failed. Runs with no expected answer are shown but do not fail.
+func print_summary(outcomes []outcome, expected map[string]map[string]string) bool {
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "solver\tinput\tname\tanswer\texpected\tstatus\ttook\t")
+
+	counts := make(map[string]int)
+	failed := false
+
+	row := func(outcome outcome, name string, answer string, want string, status string) {
+		counts[status]++
+		if status == "FAIL" || status == "ERROR" || status == "PANIC" {
+			failed = true
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			outcome.job.solver, outcome.job.input, name, answer, want, status,
+			outcome.took.Round(time.Millisecond))
+	}
+
+	for _, outcome := range outcomes {
+		day := expected[outcome.job.solver.dir]
+		key := func(name string) string {
+			return expected_key(outcome.job.solver.file, outcome.job.input, name)
+		}
+
+		for _, answer := range outcome.answers {
+			want, known := day[key(answer.Name)]
+
+			status := "?"
+			switch {
+			case answer.Partial:
+				status = "TIMEOUT"
+			case known && want == answer.answer():
+				status = "ok"
+			case known:
+				status = "FAIL"
+			}
+
+			if !known {
+				want = "-"
+			}
+
+			row(outcome, answer.Name, answer.answer(), want, status)
+		}
+
+		if outcome.err == "" {
+			continue
+		}
+
+		_, rejected := day[key("error")]
+
+		switch {
+		case outcome.panicked:
+			row(outcome, "-", outcome.err, "-", "PANIC")
+		case outcome.timed_out && len(outcome.answers) > 0:
+			// Already shown as partial answers.
+		case outcome.timed_out:
+			row(outcome, "-", outcome.err, "-", "TIMEOUT")
+		case rejected && len(outcome.answers) == 0:
+			row(outcome, "-", outcome.err, "error", "ok")
+		default:
+			row(outcome, "-", outcome.err, "-", "ERROR")
+		}
+	}
+
+	table.Flush()
+
+	rows := 0
+	statuses := make([]string, 0, len(counts))
+	for status, count := range counts {
+		rows += count
+		statuses = append(statuses, fmt.Sprintf("%d %s", count, status))
+	}
+	sort.Strings(statuses)
+
+	fmt.Printf("\n%d runs, %d rows: %s\n", len(outcomes), rows, strings.Join(statuses, ", "))
+
+	return failed
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadExpected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), expected_file)
+	os.WriteFile(path, []byte(`# Checked by hand
+part1.go real.txt Total winnings: 253603890
+
+part1.go test.txt Total: 4 (or so): 6440
+  part2.go test.txt Gear ratio (*=2:product): 467835
+part2.go test3.txt error
+`), 0644)
+
+	expected := load_expected(path)
+
+	want := map[string]string{
+		expected_key("part1.go", "real.txt", "Total winnings"):           "253603890",
+		expected_key("part1.go", "test.txt", "Total: 4 (or so)"):         "6440",
+		expected_key("part2.go", "test.txt", "Gear ratio (*=2:product)"): "467835",
+		expected_key("part2.go", "test3.txt", "error"):                   "",
+	}
+
+	if len(expected) != len(want) {
+		t.Errorf("load_expected = %q, want %q", expected, want)
+	}
+
+	for key, answer := range want {
+		if got, ok := expected[key]; !ok || got != answer {
+			t.Errorf("load_expected[%q] = %q, %v, want %q", key, got, ok, answer)
+		}
+	}
+
+	if missing := load_expected(filepath.Join(t.TempDir(), "none.txt")); len(missing) != 0 {
+		t.Errorf("load_expected on a missing file = %q, want nothing", missing)
+	}
+}
+
+func TestSaveExpected(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "day-99")
+	os.Mkdir(dir, 0755)
+
+	os.WriteFile(filepath.Join(dir, expected_file), []byte(`part1.go real.txt Total: 1
+part1.go test.txt Total: 2
+part2.go real.txt Total: 3
+part2.go test.txt Total: 4
+`), 0644)
+
+	part1 := solver{99, 1, "day-99", "part1.go"}
+	part2 := solver{99, 2, "day-99", "part2.go"}
+
+	answers := func(text string) []answer_line {
+		return []answer_line{{Name: "Total", Answer: json.RawMessage(text)}}
+	}
+
+	save_expected(root, []outcome{
+		{job: job{solver: part1, input: "test.txt"}, answers: answers(`"20"`)},
+		{job: job{solver: part1, input: "bad.txt"}, err: "Line 1: expected a number"},
+		{job: job{solver: part2, input: "real.txt"}, answers: answers(`"30"`), timed_out: true},
+		{job: job{solver: part2, input: "test.txt"}, answers: answers(`40`)},
+	})
+
+	data, err := os.ReadFile(filepath.Join(dir, expected_file))
+	if err != nil {
+		t.Fatalf("save_expected wrote nothing: %v", err)
+	}
+
+	// Runs that finished replace what was there, and those that did not
+	// keep it.
+	want := `part1.go bad.txt error
+part1.go real.txt Total: 1
+part1.go test.txt Total: 20
+part2.go real.txt Total: 3
+part2.go test.txt Total: 40
+`
+
+	if string(data) != want {
+		t.Errorf("save_expected wrote\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestAnswerLine(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`{"name":"Total","answer":"6440"}`, "6440"},
+		{`{"name":"Total","answer":6440}`, "6440"},
+		{`{"name":"Total","answer":"a \"quoted\" answer"}`, `a "quoted" answer`},
+		{`{"name":"Total","answer":123456789012345678901234567890}`, "123456789012345678901234567890"},
+	}
+
+	for _, test := range tests {
+		line := answer_line{}
+		if err := json.Unmarshal([]byte(test.json), &line); err != nil {
+			t.Errorf("Unmarshal(%s) failed: %v", test.json, err)
+			continue
+		}
+
+		if got := line.answer(); got != test.want {
+			t.Errorf("answer of %s = %q, want %q", test.json, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// One `aoc bench`. Results maps a benchmark such as
+// "day-05/BenchmarkPart1" to its metrics, such as "ns/op" and
+// "allocs/op".
+type bench_run struct {
+	// The short commit hash, with "-dirty" if there were uncommitted
+	// changes.
+	Commit    string                        `json:"commit"`
+	Date      time.Time                     `json:"date"`
+	GoVersion string                        `json:"go_version"`
+	Results   map[string]map[string]float64 `json:"results"`
+}
+
+var bench_line_regex = regexp.MustCompile(`^(Benchmark\S+?)(-\d+)?\s+(\d+)\s+(.*)$`)
+
+func bench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	history_path := flags.String("history", "bench_history.json", "JSON file of past runs, relative to the repository root")
+	benchtime := flags.String("benchtime", "1s", "passed to go test -benchtime")
+	long := flags.Bool("long", false, "also run the benchmarks that take minutes, such as day 5 part 2")
+	day_list := flags.String("days", "", "only benchmark these days, e.g. 5,12")
+	threshold := flags.Float64("threshold", 10, "mark benchmarks that got this many percent slower")
+	flags.Parse(args)
+
+	days, err := parse_days(*day_list)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	root := find_root()
+
+	current := bench_run{
+		Commit:    git_commit(root),
+		Date:      time.Now(),
+		GoVersion: runtime.Version(),
+		Results:   make(map[string]map[string]float64),
+	}
+
+	for _, solver := range find_solvers(root) {
+		if days != nil && !days[solver.day] {
+			continue
+		}
+
+		test_file := strings.TrimSuffix(solver.file, ".go") + "_test.go"
+		if _, err := os.Stat(filepath.Join(root, solver.dir, test_file)); err != nil {
+			continue
+		}
+
+		fmt.Fprintln(os.Stderr, "Benchmarking", solver)
+
+		command_args := []string{"test", "-run", "^$", "-bench", ".", "-benchtime", *benchtime}
+		if !*long {
+			command_args = append(command_args, "-short")
+		}
+		command_args = append(command_args, solver.file, test_file)
+
+		command := exec.Command("go", command_args...)
+		command.Dir = filepath.Join(root, solver.dir)
+		output, err := command.CombinedOutput()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed: %v\n%s", solver, err, output)
+			continue
+		}
+
+		results := parse_bench_output(string(output))
+		if len(results) == 0 {
+			fmt.Fprintf(os.Stderr, "%s was skipped (see -long)\n", solver)
+		}
+
+		for name, metrics := range results {
+			current.Results[solver.dir+"/"+name] = metrics
+		}
+	}
+
+	history_file := filepath.Join(root, *history_path)
+	history := load_history(history_file)
+
+	var previous *bench_run
+	if len(history) > 0 {
+		previous = &history[len(history)-1]
+	}
+
+	print_comparison(current, previous, *threshold)
+
+	// The history keeps one run per commit, the latest.
+	kept := make([]bench_run, 0, len(history))
+	for _, past := range history {
+		if past.Commit != current.Commit {
+			kept = append(kept, past)
+		}
+	}
+
+	save_history(history_file, append(kept, current))
+}
+
+// The metrics of each benchmark in `go test -bench` output, by name.
+func parse_bench_output(output string) map[string]map[string]float64 {
+	results := make(map[string]map[string]float64)
+
+	for _, line := range strings.Split(output, "\n") {
+		match := bench_line_regex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		metrics := make(map[string]float64)
+
+		fields := strings.Fields(match[4])
+		for i := 0; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			metrics[fields[i+1]] = value
+		}
+
+		results[match[1]] = metrics
+	}
+
+	return results
+}
+
+func git_commit(root string) string {
+	command := exec.Command("git", "rev-parse", "--short", "HEAD")
+	command.Dir = root
+	output, err := command.Output()
+	if err != nil {
+		log.Fatal("Could not find the git commit: ", err)
+	}
+
+	commit := strings.TrimSpace(string(output))
+
+	command = exec.Command("git", "status", "--porcelain", "--untracked-files=no")
+	command.Dir = root
+	output, err = command.Output()
+	if err != nil {
+		log.Fatal("Could not check for uncommitted changes: ", err)
+	}
+
+	if len(strings.TrimSpace(string(output))) > 0 {
+		commit += "-dirty"
+	}
+
+	return commit
+}
+
+func load_history(path string) []bench_run {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		log.Fatal("Could not read history: ", err)
+	}
+
+	history := make([]bench_run, 0)
+	if err := json.Unmarshal(data, &history); err != nil {
+		log.Fatal("Could not parse history ", path, ": ", err)
+	}
+
+	return history
+}
+
+func save_history(path string, history []bench_run) {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		log.Fatal("Could not encode history: ", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		log.Fatal("Could not write history: ", err)
+	}
+}
+
+func format_metric(metrics map[string]float64, unit string) string {
+	value, ok := metrics[unit]
+	if !ok {
+		return "-"
+	}
+
+	if unit == "ns/op" {
+		return time.Duration(value).Round(time.Microsecond).String()
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Prints each benchmark's time and allocations next to the previous run's,
+// with the change in time.
+func print_comparison(current bench_run, previous *bench_run, threshold float64) {
+	names := make([]string, 0, len(current.Results))
+	for name := range current.Results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if previous != nil {
+		fmt.Printf("Comparing %s with %s (%s)\n\n", current.Commit, previous.Commit, previous.Date.Format(time.DateTime))
+	} else {
+		fmt.Printf("No earlier run to compare %s with\n\n", current.Commit)
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "benchmark\tbefore\tafter\tchange\tallocs before\tallocs after\t")
+
+	for _, name := range names {
+		after := current.Results[name]
+		before := map[string]float64{}
+		if previous != nil && previous.Results[name] != nil {
+			before = previous.Results[name]
+		}
+
+		change := "-"
+		verdict := ""
+
+		if old, ok := before["ns/op"]; ok && old > 0 {
+			percent := (after["ns/op"] - old) / old * 100
+			change = fmt.Sprintf("%+.1f%%", percent)
+
+			if percent > threshold {
+				verdict = "SLOWER"
+			} else if percent < -threshold {
+				verdict = "faster"
+			}
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			name,
+			format_metric(before, "ns/op"), format_metric(after, "ns/op"), change,
+			format_metric(before, "allocs/op"), format_metric(after, "allocs/op"),
+			verdict)
+	}
+
+	table.Flush()
+}
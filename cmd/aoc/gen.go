@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// Writes a valid input for one day. The same seed and size always give
+// the same input.
+type generator struct {
+	generate func(random *rand.Rand, size int, output io.Writer)
+	// What -size means for this day.
+	size        string
+	description string
+}
+
+var generators = map[int]generator{
+	5:  {generate_almanac, "seed ranges; maps have twice as many", "almanacs whose ranges overlap the seed ranges"},
+	10: {generate_pipes, "width and height", "a pipe loop among junk pipes"},
+	12: {generate_springs, "rows", "spring rows with many unknowns, slow to unfold"},
+	16: {generate_contraption, "width and height", "a grid of mirrors and splitters"},
+	19: {generate_workflows, "workflows; as many parts", "acyclic workflows and parts to sort"},
+}
+
+func gen(args []string) {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	day := flags.Int("day", 0, "which day to generate an input for")
+	size := flags.Int("size", 10, "how big an input to make; what it counts depends on the day")
+	seed := flags.Int64("seed", 1, "random seed; the same seed and size give the same input")
+	output_path := flags.String("o", "", "write to this file instead of stdout")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: aoc gen -day <day> [-size <n>] [-seed <n>] [-o <file>]")
+		flags.PrintDefaults()
+		print_generators(flags.Output())
+	}
+	flags.Parse(args)
+
+	generator, ok := generators[*day]
+	if !ok {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *size < 1 {
+		log.Fatal("-size must be at least 1")
+	}
+
+	var output io.Writer = os.Stdout
+
+	if *output_path != "" {
+		file, err := os.Create(*output_path)
+		if err != nil {
+			log.Fatal("Could not create output: ", err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	buffered := bufio.NewWriter(output)
+	generator.generate(rand.New(rand.NewSource(*seed)), *size, buffered)
+
+	if err := buffered.Flush(); err != nil {
+		log.Fatal("Could not write input: ", err)
+	}
+}
+
+func print_generators(output io.Writer) {
+	days := make([]int, 0, len(generators))
+	for day := range generators {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+
+	fmt.Fprintln(output, "Days:")
+	for _, day := range days {
+		fmt.Fprintf(output, "  %2d  %s (-size: %s)\n", day, generators[day].description, generators[day].size)
+	}
+}
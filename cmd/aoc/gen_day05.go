@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+var almanac_categories = []string{"seed", "soil", "fertilizer", "water", "light", "temperature", "humidity", "location"}
+
+// n distinct numbers below limit, in order.
+func distinct_sorted(random *rand.Rand, n int, limit int) []int {
+	seen := make(map[int]bool)
+	numbers := make([]int, 0, n)
+
+	for len(numbers) < n {
+		number := random.Intn(limit)
+		if !seen[number] {
+			seen[number] = true
+			numbers = append(numbers, number)
+		}
+	}
+
+	sort.Ints(numbers)
+	return numbers
+}
+
+// Seed ranges anywhere in the number line, and maps whose source ranges
+// are cut without regard to them, so most seed ranges straddle several,
+// and the gaps between, which map numbers to themselves. Part 2 tries
+// every seed against every range, so its time grows with size squared.
+func generate_almanac(random *rand.Rand, size int, output io.Writer) {
+	span := 100_000 * size
+
+	fmt.Fprint(output, "seeds:")
+	for _, start := range distinct_sorted(random, size, span) {
+		length := 1 + random.Intn(span/size)
+		fmt.Fprintf(output, " %d %d", start, length)
+	}
+	fmt.Fprintln(output)
+	fmt.Fprintln(output)
+
+	for i := 0; i+1 < len(almanac_categories); i++ {
+		fmt.Fprintf(output, "%s-to-%s map:\n", almanac_categories[i], almanac_categories[i+1])
+
+		// Pairs of cuts are the ranges, and what lies between pairs is
+		// left unmapped.
+		cuts := distinct_sorted(random, 4*size, span)
+		order := random.Perm(2 * size)
+
+		for _, j := range order {
+			from, to := cuts[2*j], cuts[2*j+1]
+			fmt.Fprintf(output, "%d %d %d\n", random.Intn(span), from, to-from)
+		}
+
+		// Every block, the last too, ends with a blank line.
+		fmt.Fprintln(output)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// The pipe joining each pair of directions a tile can connect.
+var pipe_for = map[[2]byte]byte{
+	{'N', 'S'}: '|',
+	{'E', 'W'}: '-',
+	{'N', 'E'}: 'L',
+	{'N', 'W'}: 'J',
+	{'S', 'W'}: '7',
+	{'S', 'E'}: 'F',
+}
+
+const junk_pipes = "|-LJ7F"
+
+// A loop around a random blob of cells, among random pipes that lead
+// nowhere. The tiles are the corners of the cells, so the loop runs
+// between blob and not-blob. The blob has no holes and never touches
+// itself only at a corner, so the loop is one closed path that never
+// crosses itself.
+func generate_pipes(random *rand.Rand, size int, output io.Writer) {
+	size = max(size, 3)
+	cells := size - 1
+
+	inside := make([][]bool, cells)
+	for y := range inside {
+		inside[y] = make([]bool, cells)
+	}
+
+	is_inside := func(x int, y int) bool {
+		return x >= 0 && y >= 0 && x < cells && y < cells && inside[y][x]
+	}
+
+	// Whether adding the cell would leave a diagonal neighbour joined to
+	// it only at a corner.
+	corner_only := func(x int, y int) bool {
+		for _, d := range [][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+			if is_inside(x+d[0], y+d[1]) && !is_inside(x+d[0], y) && !is_inside(x, y+d[1]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	blob := [][2]int{{cells / 2, cells / 2}}
+	inside[cells/2][cells/2] = true
+
+	target := cells * cells / 2
+	for attempt := 0; len(blob) < target && attempt < 20*target; attempt++ {
+		from := blob[random.Intn(len(blob))]
+		step := directions_4[random.Intn(4)]
+		x, y := from[0]+step[0], from[1]+step[1]
+
+		if x < 0 || y < 0 || x >= cells || y >= cells || inside[y][x] || corner_only(x, y) {
+			continue
+		}
+
+		inside[y][x] = true
+		blob = append(blob, [2]int{x, y})
+	}
+
+	fill_holes(inside)
+
+	tiles := make([][]byte, size)
+	loop := make([][2]int, 0)
+	on_loop := make([][]bool, size)
+
+	for y := 0; y < size; y++ {
+		tiles[y] = make([]byte, size)
+		on_loop[y] = make([]bool, size)
+
+		for x := 0; x < size; x++ {
+			// The corner at the top left of cell (x, y). Each side of it
+			// is on the loop if it has the blob on one side only.
+			ends := make([]byte, 0, 2)
+			if is_inside(x-1, y-1) != is_inside(x, y-1) {
+				ends = append(ends, 'N')
+			}
+			if is_inside(x-1, y) != is_inside(x, y) {
+				ends = append(ends, 'S')
+			}
+			if is_inside(x-1, y-1) != is_inside(x-1, y) {
+				ends = append(ends, 'W')
+			}
+			if is_inside(x, y-1) != is_inside(x, y) {
+				ends = append(ends, 'E')
+			}
+
+			if len(ends) == 2 {
+				pipe, ok := pipe_for[[2]byte{ends[0], ends[1]}]
+				if !ok {
+					pipe = pipe_for[[2]byte{ends[1], ends[0]}]
+				}
+				tiles[y][x] = pipe
+				loop = append(loop, [2]int{x, y})
+				on_loop[y][x] = true
+			} else if random.Intn(10) < 6 {
+				tiles[y][x] = junk_pipes[random.Intn(len(junk_pipes))]
+			} else {
+				tiles[y][x] = '.'
+			}
+		}
+	}
+
+	start := loop[random.Intn(len(loop))]
+	tiles[start[1]][start[0]] = 'S'
+
+	// Only the two pipes on the loop may join S, as the puzzle promises.
+	for _, next := range []struct {
+		step [2]int
+		// The pipes that would join S from that side.
+		joining string
+	}{
+		{[2]int{0, -1}, "|7F"},
+		{[2]int{0, 1}, "|LJ"},
+		{[2]int{-1, 0}, "-LF"},
+		{[2]int{1, 0}, "-J7"},
+	} {
+		x, y := start[0]+next.step[0], start[1]+next.step[1]
+		if x < 0 || y < 0 || x >= size || y >= size || on_loop[y][x] {
+			continue
+		}
+
+		if strings.IndexByte(next.joining, tiles[y][x]) >= 0 {
+			tiles[y][x] = '.'
+		}
+	}
+
+	for _, row := range tiles {
+		output.Write(row)
+		output.Write([]byte{'\n'})
+	}
+}
+
+var directions_4 = [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+// Adds to the blob every cell that cannot reach the edge of the grid
+// without crossing it.
+func fill_holes(inside [][]bool) {
+	height, width := len(inside), len(inside[0])
+
+	outside := make([][]bool, height)
+	for y := range outside {
+		outside[y] = make([]bool, width)
+	}
+
+	to_visit := make([][2]int, 0)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x == 0 || y == 0 || x == width-1 || y == height-1) && !inside[y][x] {
+				outside[y][x] = true
+				to_visit = append(to_visit, [2]int{x, y})
+			}
+		}
+	}
+
+	for len(to_visit) > 0 {
+		cell := to_visit[len(to_visit)-1]
+		to_visit = to_visit[:len(to_visit)-1]
+
+		for _, step := range directions_4 {
+			x, y := cell[0]+step[0], cell[1]+step[1]
+			if x >= 0 && y >= 0 && x < width && y < height && !inside[y][x] && !outside[y][x] {
+				outside[y][x] = true
+				to_visit = append(to_visit, [2]int{x, y})
+			}
+		}
+	}
+
+	for y := range inside {
+		for x := range inside[y] {
+			inside[y][x] = !outside[y][x]
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// Rows made by laying out groups of broken springs and then forgetting
+// about half of the springs, so every row has at least one arrangement.
+// Rows up to 20 long with that many unknowns have thousands of
+// arrangements once unfolded.
+func generate_springs(random *rand.Rand, size int, output io.Writer) {
+	for i := 0; i < size; i++ {
+		length := 8 + random.Intn(13)
+		springs := []byte(strings.Repeat(".", length))
+		groups := make([]string, 0)
+
+		for position := random.Intn(3); position < length; {
+			group := 1 + random.Intn(min(5, length-position))
+
+			for j := position; j < position+group; j++ {
+				springs[j] = '#'
+			}
+			groups = append(groups, fmt.Sprint(group))
+
+			position += group + 1 + random.Intn(3)
+		}
+
+		for j := range springs {
+			if random.Intn(2) == 0 {
+				springs[j] = '?'
+			}
+		}
+
+		fmt.Fprintf(output, "%s %s\n", springs, strings.Join(groups, ","))
+	}
+}
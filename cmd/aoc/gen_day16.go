@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+)
+
+const contraption_parts = `/\|-`
+
+// A square of mostly empty space with mirrors and splitters scattered
+// through it, about one tile in eight.
+func generate_contraption(random *rand.Rand, size int, output io.Writer) {
+	row := make([]byte, size+1)
+	row[size] = '\n'
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if random.Intn(8) == 0 {
+				row[x] = contraption_parts[random.Intn(len(contraption_parts))]
+			} else {
+				row[x] = '.'
+			}
+		}
+
+		output.Write(row)
+	}
+}
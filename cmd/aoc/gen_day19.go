@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+const workflow_letters = "abcdefghijklmnopqrstuvwxyz"
+
+// Workflows that only send parts on to workflows after them, starting
+// from in, so no part can go round in a loop, then as many parts.
+func generate_workflows(random *rand.Rand, size int, output io.Writer) {
+	names := []string{"in"}
+	taken := map[string]bool{"in": true}
+
+	for len(names) < size {
+		name := make([]byte, 2+random.Intn(2))
+		for i := range name {
+			name[i] = workflow_letters[random.Intn(len(workflow_letters))]
+		}
+
+		if !taken[string(name)] {
+			taken[string(name)] = true
+			names = append(names, string(name))
+		}
+	}
+
+	// A workflow after the i-th, or a verdict.
+	target := func(i int) string {
+		later := len(names) - i - 1
+		if later == 0 || random.Intn(4) == 0 {
+			return string("AR"[random.Intn(2)])
+		}
+		return names[i+1+random.Intn(later)]
+	}
+
+	for i, name := range names {
+		rules := make([]string, 0)
+
+		for j := 1 + random.Intn(4); j > 0; j-- {
+			rules = append(rules, fmt.Sprintf("%c%c%d:%s", "xmas"[random.Intn(4)], "<>"[random.Intn(2)], 1+random.Intn(4000), target(i)))
+		}
+		rules = append(rules, target(i))
+
+		fmt.Fprintf(output, "%s{%s}\n", name, strings.Join(rules, ","))
+	}
+
+	fmt.Fprintln(output)
+
+	for i := 0; i < size; i++ {
+		fmt.Fprintf(output, "{x=%d,m=%d,a=%d,s=%d}\n", 1+random.Intn(4000), 1+random.Intn(4000), 1+random.Intn(4000), 1+random.Intn(4000))
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"advent-of-code/day-19/workflow"
+)
+
+func generate(day int, seed int64, size int) string {
+	output := bytes.Buffer{}
+	generators[day].generate(rand.New(rand.NewSource(seed)), size, &output)
+	return output.String()
+}
+
+func TestGeneratorsAreDeterministic(t *testing.T) {
+	for day := range generators {
+		for _, size := range []int{1, 3, 20} {
+			first, second := generate(day, 1, size), generate(day, 1, size)
+
+			if first != second {
+				t.Errorf("day %d, size %d: seed 1 gave two different inputs", day, size)
+			}
+		}
+
+		if generate(day, 1, 20) == generate(day, 2, 20) {
+			t.Errorf("day %d: seeds 1 and 2 gave the same input", day)
+		}
+	}
+}
+
+var almanac_numbers_regex = regexp.MustCompile(`^\d+ \d+ \d+$`)
+
+// A seeds line with size ranges, then each map with twice as many.
+func check_almanac(input string, size int) error {
+	blocks := strings.Split(strings.TrimSuffix(input, "\n\n"), "\n\n")
+
+	if len(blocks) != len(almanac_categories) {
+		return fmt.Errorf("%d blocks, want %d", len(blocks), len(almanac_categories))
+	}
+
+	if seeds := strings.Fields(blocks[0]); seeds[0] != "seeds:" || len(seeds) != 1+2*size {
+		return fmt.Errorf("seeds line %q", blocks[0])
+	}
+
+	for i, block := range blocks[1:] {
+		lines := strings.Split(block, "\n")
+
+		if header := almanac_categories[i] + "-to-" + almanac_categories[i+1] + " map:"; lines[0] != header {
+			return fmt.Errorf("map header %q, want %q", lines[0], header)
+		}
+
+		if len(lines) != 1+2*size {
+			return fmt.Errorf("%s has %d ranges, want %d", lines[0], len(lines)-1, 2*size)
+		}
+
+		for _, line := range lines[1:] {
+			if !almanac_numbers_regex.MatchString(line) {
+				return fmt.Errorf("range %q in %s", line, lines[0])
+			}
+		}
+	}
+
+	return nil
+}
+
+// The directions each pipe joins, as steps.
+var pipe_steps = map[byte][2][2]int{
+	'|': {{0, -1}, {0, 1}},
+	'-': {{-1, 0}, {1, 0}},
+	'L': {{0, -1}, {1, 0}},
+	'J': {{0, -1}, {-1, 0}},
+	'7': {{0, 1}, {-1, 0}},
+	'F': {{0, 1}, {1, 0}},
+}
+
+// A square of tiles with one S, joined by exactly two pipes to a loop
+// that leads back to it.
+func check_pipes(input string, size int) error {
+	rows := strings.Split(strings.TrimSuffix(input, "\n"), "\n")
+	size = max(size, 3)
+
+	start := [2]int{-1, -1}
+
+	for y, row := range rows {
+		if len(rows) != size || len(row) != size {
+			return fmt.Errorf("%d rows, row %d is %d long, want %d by %d", len(rows), y, len(row), size, size)
+		}
+
+		for x := range row {
+			if row[x] == 'S' {
+				if start[0] >= 0 {
+					return fmt.Errorf("two starts, at %v and %d,%d", start, x, y)
+				}
+				start = [2]int{x, y}
+			} else if _, ok := pipe_steps[row[x]]; !ok && row[x] != '.' {
+				return fmt.Errorf("tile %q at %d,%d", row[x], x, y)
+			}
+		}
+	}
+
+	if start[0] < 0 {
+		return fmt.Errorf("no start")
+	}
+
+	tile := func(p [2]int) byte {
+		if p[0] < 0 || p[1] < 0 || p[0] >= size || p[1] >= size {
+			return '.'
+		}
+		return rows[p[1]][p[0]]
+	}
+
+	joins := func(from [2]int, to [2]int) bool {
+		for _, step := range pipe_steps[tile(to)] {
+			if to[0]+step[0] == from[0] && to[1]+step[1] == from[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	neighbours := make([][2]int, 0)
+	for _, step := range directions_4 {
+		next := [2]int{start[0] + step[0], start[1] + step[1]}
+		if joins(start, next) {
+			neighbours = append(neighbours, next)
+		}
+	}
+
+	if len(neighbours) != 2 {
+		return fmt.Errorf("%d pipes join the start, want 2", len(neighbours))
+	}
+
+	previous, current := start, neighbours[0]
+
+	for steps := 1; current != start; steps++ {
+		if steps > size*size {
+			return fmt.Errorf("the loop from the start never comes back")
+		}
+
+		next := previous
+		for _, step := range pipe_steps[tile(current)] {
+			if candidate := [2]int{current[0] + step[0], current[1] + step[1]}; candidate != previous {
+				next = candidate
+			}
+		}
+
+		if next != start && !joins(current, next) {
+			return fmt.Errorf("the loop breaks at %v", current)
+		}
+
+		previous, current = current, next
+	}
+
+	if previous != neighbours[1] {
+		return fmt.Errorf("the loop comes back to the start from %v, not %v", previous, neighbours[1])
+	}
+
+	return nil
+}
+
+// Whether the springs, from position on, can hold the groups.
+func can_arrange(springs string, groups []int) bool {
+	if len(groups) == 0 {
+		return !strings.Contains(springs, "#")
+	}
+
+	for start := 0; start+groups[0] <= len(springs); start++ {
+		end := start + groups[0]
+
+		if !strings.Contains(springs[start:end], ".") && (end == len(springs) || springs[end] != '#') {
+			rest := ""
+			if end < len(springs) {
+				rest = springs[end+1:]
+			}
+			if can_arrange(rest, groups[1:]) {
+				return true
+			}
+		}
+
+		if springs[start] == '#' {
+			break
+		}
+	}
+
+	return false
+}
+
+// size rows, each with at least one arrangement.
+func check_springs(input string, size int) error {
+	rows := strings.Split(strings.TrimSuffix(input, "\n"), "\n")
+
+	if len(rows) != size {
+		return fmt.Errorf("%d rows, want %d", len(rows), size)
+	}
+
+	for _, row := range rows {
+		fields := strings.Fields(row)
+		if len(fields) != 2 || strings.Trim(fields[0], ".#?") != "" {
+			return fmt.Errorf("row %q", row)
+		}
+
+		groups := make([]int, 0)
+		for _, group := range strings.Split(fields[1], ",") {
+			number, err := strconv.Atoi(group)
+			if err != nil || number < 1 {
+				return fmt.Errorf("group %q in row %q", group, row)
+			}
+			groups = append(groups, number)
+		}
+
+		if !can_arrange(fields[0], groups) {
+			return fmt.Errorf("row %q has no arrangement", row)
+		}
+	}
+
+	return nil
+}
+
+// A square of empty space, mirrors and splitters.
+func check_contraption(input string, size int) error {
+	rows := strings.Split(strings.TrimSuffix(input, "\n"), "\n")
+
+	for y, row := range rows {
+		if len(rows) != size || len(row) != size {
+			return fmt.Errorf("%d rows, row %d is %d long, want %d by %d", len(rows), y, len(row), size, size)
+		}
+
+		if strings.Trim(row, "."+contraption_parts) != "" {
+			return fmt.Errorf("row %q", row)
+		}
+	}
+
+	return nil
+}
+
+// Workflows that parse, which rules out loops, and size parts.
+func check_workflows(input string, size int) error {
+	program, err := workflow.Parse(input)
+	if err != nil {
+		return err
+	}
+
+	if len(program.Parts) != size {
+		return fmt.Errorf("%d parts, want %d", len(program.Parts), size)
+	}
+
+	return nil
+}
+
+func TestGeneratedInputs(t *testing.T) {
+	checks := map[int]func(input string, size int) error{
+		5:  check_almanac,
+		10: check_pipes,
+		12: check_springs,
+		16: check_contraption,
+		19: check_workflows,
+	}
+
+	for day := range generators {
+		check, ok := checks[day]
+		if !ok {
+			t.Errorf("day %d has a generator but nothing to check its inputs", day)
+			continue
+		}
+
+		for _, size := range []int{1, 2, 5, 30} {
+			for seed := int64(1); seed <= 20; seed++ {
+				if err := check(generate(day, seed, size), size); err != nil {
+					t.Errorf("day %d, size %d, seed %d: %v", day, size, seed, err)
+				}
+			}
+		}
+	}
+}
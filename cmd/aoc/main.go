@@ -0,0 +1,151 @@
+// Command aoc works on every day's solvers at once. Run it from anywhere
+// in the repository:
+//
+//	go run ./cmd/aoc all
+//	go run ./cmd/aoc bench
+//
+// Each command has its own flags; see `aoc <command> -h`.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+type command struct {
+	run         func(args []string)
+	description string
+}
+
+var commands = map[string]command{
+	"all":      {all, "run every day on every input and check the answers"},
+	"bench":    {bench, "benchmark every day and compare with the previous run"},
+	"gen":      {gen, "generate a random input for a day"},
+	"minimize": {minimize, "shrink an input that shows a bug to a minimal reproducer"},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: aoc <command> [flags]")
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].description)
+	}
+
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	command.run(os.Args[2:])
+}
+
+// One part of one day, as found on disk.
+type solver struct {
+	day  int
+	part int
+	// Relative to the repository root, e.g. "day-05".
+	dir string
+	// e.g. "part2.go".
+	file string
+}
+
+func (solver solver) String() string {
+	return fmt.Sprintf("%s/%s", solver.dir, solver.file)
+}
+
+var solver_regex = regexp.MustCompile(`^day-(\d+)/part(\d)\.go$`)
+
+// The directory holding go.mod, found by walking up from the working
+// directory.
+func find_root() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			log.Fatal("Not inside the repository: no go.mod found")
+		}
+		dir = parent
+	}
+}
+
+// Every Go solver in the repository, by day and then part.
+func find_solvers(root string) []solver {
+	paths, err := filepath.Glob(filepath.Join(root, "day-*", "part*.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	solvers := make([]solver, 0)
+
+	for _, path := range paths {
+		relative, _ := filepath.Rel(root, path)
+
+		match := solver_regex.FindStringSubmatch(filepath.ToSlash(relative))
+		if match == nil {
+			continue
+		}
+
+		day, _ := strconv.Atoi(match[1])
+		part, _ := strconv.Atoi(match[2])
+
+		solvers = append(solvers, solver{day, part, filepath.Dir(relative), filepath.Base(relative)})
+	}
+
+	sort.Slice(solvers, func(i, j int) bool {
+		if solvers[i].day != solvers[j].day {
+			return solvers[i].day < solvers[j].day
+		}
+		return solvers[i].part < solvers[j].part
+	})
+
+	return solvers
+}
+
+// Parses a list of days such as "5,12", or "" for all of them.
+func parse_days(list string) (map[int]bool, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	days := make(map[int]bool)
+
+	for _, field := range regexp.MustCompile(`\s*,\s*`).Split(list, -1) {
+		day, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("bad day %q in %q", field, list)
+		}
+		days[day] = true
+	}
+
+	return days, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		list string
+		want []int
+		ok   bool
+	}{
+		{"", nil, true},
+		{"5", []int{5}, true},
+		{"5,12", []int{5, 12}, true},
+		{"5 , 12,5", []int{5, 12}, true},
+		{"5,,12", nil, false},
+		{"five", nil, false},
+	}
+
+	for _, test := range tests {
+		days, err := parse_days(test.list)
+
+		if (err == nil) != test.ok {
+			t.Errorf("parse_days(%q) error = %v, want ok %v", test.list, err, test.ok)
+			continue
+		}
+
+		got := []int{}
+		for day := range days {
+			got = append(got, day)
+		}
+		slices.Sort(got)
+
+		if test.ok && !slices.Equal(got, test.want) {
+			t.Errorf("parse_days(%q) = %v, want %v", test.list, got, test.want)
+		}
+	}
+}
+
+func TestFindSolvers(t *testing.T) {
+	root := t.TempDir()
+
+	for _, path := range []string{
+		"day-10/part2.go",
+		"day-10/part1.go",
+		"day-02/part1.go",
+		"day-02/part1_test.go",
+		"day-02/cubes/cubes.go",
+		"day-02/part1.py",
+		"day-03/helper.go",
+		"notes/part1.go",
+	} {
+		os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755)
+		os.WriteFile(filepath.Join(root, path), nil, 0644)
+	}
+
+	solvers := find_solvers(root)
+
+	want := []solver{
+		{2, 1, "day-02", "part1.go"},
+		{10, 1, "day-10", "part1.go"},
+		{10, 2, "day-10", "part2.go"},
+	}
+
+	if !slices.Equal(solvers, want) {
+		t.Errorf("find_solvers = %v, want %v", solvers, want)
+	}
+}
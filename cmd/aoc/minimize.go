@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// What makes an input interesting to the minimizer, given how the
+// solvers did on it.
+var checks = map[string]struct {
+	holds func(a outcome, b outcome) bool
+	// Whether it looks at the second solver.
+	compares    bool
+	description string
+}{
+	"disagree": {
+		func(a outcome, b outcome) bool {
+			return a.err == "" && b.err == "" && !slices.Equal(answers_of(a), answers_of(b))
+		},
+		true,
+		"both solvers finish, with different answers",
+	},
+	"panics": {
+		func(a outcome, b outcome) bool { return a.panicked },
+		false,
+		"the first solver panics",
+	},
+}
+
+func answers_of(outcome outcome) []string {
+	answers := make([]string, len(outcome.answers))
+	for i, answer := range outcome.answers {
+		answers[i] = answer.answer()
+	}
+	return answers
+}
+
+// Tries inputs on the solvers, and remembers what it found.
+type minimizer struct {
+	root     string
+	a, b     job
+	binaries map[solver]string
+	holds    func(a outcome, b outcome) bool
+	compares bool
+	timeout  time.Duration
+
+	// Where candidates are written for the solvers to read.
+	scratch string
+	tried   map[string]bool
+	runs    int
+}
+
+func minimize(args []string) {
+	flags := flag.NewFlagSet("minimize", flag.ExitOnError)
+	day := flags.Int("day", 0, "the day whose solvers to run")
+	check := flags.String("check", "disagree", "what the input must keep doing: disagree or panics")
+	first := flags.String("a", "part1.go", "the solver, with any flags, e.g. \"part2.go -unfold 1\"")
+	second := flags.String("b", "part2.go", "the solver to compare with, for -check disagree")
+	timeout := flags.Duration("timeout", 10*time.Second, "passed to each solver's -timeout; a run that times out does not count")
+	output_path := flags.String("o", "", "where to write the reproducer (default <input>-min.txt)")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: aoc minimize -day <day> [flags] <input>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	checker, ok := checks[*check]
+	if *day == 0 || flags.NArg() != 1 || !ok {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	input_path, err := filepath.Abs(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := os.ReadFile(input_path)
+	if err != nil {
+		log.Fatal("Could not read input: ", err)
+	}
+
+	if *output_path == "" {
+		*output_path = strings.TrimSuffix(input_path, filepath.Ext(input_path)) + "-min.txt"
+	}
+
+	root := find_root()
+
+	bin, err := os.MkdirTemp("", "aoc-minimize-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(bin)
+
+	m := &minimizer{
+		root:     root,
+		holds:    checker.holds,
+		compares: checker.compares,
+		timeout:  *timeout,
+		scratch:  filepath.Join(bin, "candidate.txt"),
+		tried:    make(map[string]bool),
+	}
+
+	m.a = find_job(root, *day, *first, m.scratch)
+	solvers := []solver{m.a.solver}
+
+	if m.compares {
+		m.b = find_job(root, *day, *second, m.scratch)
+		solvers = slices.Compact(append(solvers, m.b.solver))
+	}
+
+	m.binaries = build_solvers(root, bin, solvers, 2)
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	if !m.test(lines) {
+		log.Fatalf("%s does not show the problem (%s) to begin with", flags.Arg(0), checker.description)
+	}
+
+	begin := time.Now()
+
+	// Removing characters can make lines removable, and the other way
+	// round, so this goes until neither finds anything.
+	for {
+		before := strings.Join(lines, "\n")
+
+		lines = ddmin(lines, m.test)
+
+		for i := range lines {
+			chars := strings.Split(lines[i], "")
+			chars = ddmin(chars, func(candidate []string) bool {
+				trial := slices.Clone(lines)
+				trial[i] = strings.Join(candidate, "")
+				return m.test(trial)
+			})
+			lines[i] = strings.Join(chars, "")
+		}
+
+		if strings.Join(lines, "\n") == before {
+			break
+		}
+	}
+
+	text := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(*output_path, []byte(text), 0644); err != nil {
+		log.Fatal("Could not write reproducer: ", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Shrank %d bytes to %d in %d runs (%s); wrote %s\n",
+		len(data), len(text), m.runs, time.Since(begin).Round(time.Millisecond), *output_path)
+	fmt.Print(text)
+}
+
+// The solver named in a -a or -b flag, such as "part2.go -unfold 1".
+func find_job(root string, day int, command string, input string) job {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		log.Fatal("No solver given")
+	}
+
+	for _, solver := range find_solvers(root) {
+		if solver.day == day && solver.file == fields[0] {
+			return job{solver: solver, input: input, args: fields[1:]}
+		}
+	}
+
+	log.Fatalf("No solver %s for day %d", fields[0], day)
+	return job{}
+}
+
+// Whether the lines, as an input, still show the problem.
+func (m *minimizer) test(lines []string) bool {
+	text := strings.Join(lines, "\n") + "\n"
+
+	if result, seen := m.tried[text]; seen {
+		return result
+	}
+
+	if err := os.WriteFile(m.scratch, []byte(text), 0644); err != nil {
+		log.Fatal("Could not write candidate: ", err)
+	}
+
+	m.runs++
+
+	a := run_job(m.root, m.binaries[m.a.solver], m.a, m.timeout)
+	b := outcome{}
+	// Most candidates do not even parse, so the second solver only runs
+	// when the first managed.
+	if m.compares && a.err == "" {
+		b = run_job(m.root, m.binaries[m.b.solver], m.b, m.timeout)
+	}
+
+	result := !a.timed_out && !b.timed_out && m.holds(a, b)
+	m.tried[text] = result
+
+	return result
+}
+
+// Zeller's delta debugging, removing pieces only: the smallest list it
+// can find, by taking away ever smaller chunks, for which holds is still
+// true. holds must be true of the whole list.
+func ddmin(items []string, holds func([]string) bool) []string {
+	chunks := 2
+
+	for len(items) >= 2 {
+		size := (len(items) + chunks - 1) / chunks
+		removed := false
+
+		for start := 0; start < len(items); start += size {
+			rest := append(slices.Clone(items[:start]), items[min(start+size, len(items)):]...)
+
+			if holds(rest) {
+				items = rest
+				chunks = max(chunks-1, 2)
+				removed = true
+				break
+			}
+		}
+
+		if !removed {
+			if chunks >= len(items) {
+				break
+			}
+			chunks = min(chunks*2, len(items))
+		}
+	}
+
+	return items
+}
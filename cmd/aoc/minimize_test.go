@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDdmin(t *testing.T) {
+	letters := strings.Split("abcdefghij", "")
+
+	contains := func(wanted ...string) func([]string) bool {
+		return func(items []string) bool {
+			for _, item := range wanted {
+				if !slices.Contains(items, item) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	tests := []struct {
+		name  string
+		items []string
+		holds func([]string) bool
+		want  []string
+	}{
+		{"one needed", letters, contains("g"), []string{"g"}},
+		{"two apart", letters, contains("b", "i"), []string{"b", "i"}},
+		{"neighbours", letters, contains("d", "e", "f"), []string{"d", "e", "f"}},
+		{"all needed", letters, contains(letters...), letters},
+		{"length", letters, func(items []string) bool { return len(items) >= 3 }, nil},
+		// Even if the bug needs nothing, one item is left.
+		{"anything", letters, func([]string) bool { return true }, nil},
+		{"one item", []string{"a"}, contains("a"), []string{"a"}},
+		{"no items", []string{}, func([]string) bool { return true }, []string{}},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		holds := func(items []string) bool {
+			calls++
+			return test.holds(items)
+		}
+
+		got := ddmin(test.items, holds)
+
+		if test.want != nil && !slices.Equal(got, test.want) {
+			t.Errorf("%s: ddmin = %v, want %v", test.name, got, test.want)
+		}
+
+		if len(test.items) > 0 && !test.holds(got) {
+			t.Errorf("%s: ddmin = %v, which does not hold", test.name, got)
+		}
+
+		// No single item can be taken away from what is left.
+		for i := range got {
+			if len(got) > 1 && test.holds(slices.Delete(slices.Clone(got), i, i+1)) {
+				t.Errorf("%s: ddmin = %v, which still holds without %s", test.name, got, got[i])
+			}
+		}
+
+		if calls > len(test.items)*len(test.items) {
+			t.Errorf("%s: ddmin tried %d lists of %d items", test.name, calls, len(test.items))
+		}
+	}
+}
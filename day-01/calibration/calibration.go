@@ -0,0 +1,211 @@
+// Package calibration finds the first and last digit on each line of the
+// day 1 calibration document, where digits may also be spelled out. All
+// tokens are matched in one pass with an Aho-Corasick automaton, so
+// overlapping words such as "twone" give both "two" and "one".
+package calibration
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"advent-of-code/aoc"
+)
+
+// Maps each token to the digit it stands for.
+type Vocabulary map[string]int
+
+var Digits = Vocabulary{
+	"0": 0, "1": 1, "2": 2, "3": 3, "4": 4,
+	"5": 5, "6": 6, "7": 7, "8": 8, "9": 9,
+}
+
+var English = Vocabulary{
+	"one":   1,
+	"two":   2,
+	"three": 3,
+	"four":  4,
+	"five":  5,
+	"six":   6,
+	"seven": 7,
+	"eight": 8,
+	"nine":  9,
+}
+
+// Reads a vocabulary with one "<token> <digit>" pair per line, e.g.
+// "eins 1". Blank lines and lines starting with # are ignored.
+func LoadVocabulary(path string) (Vocabulary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vocabulary := Vocabulary{}
+	input := aoc.NewInput(file, path)
+
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, input.At(0, "\"<token> <digit>\"", fmt.Sprintf("%d fields", len(fields)))
+		}
+
+		digit, err := strconv.Atoi(fields[1])
+		if err != nil || digit < 0 || digit > 9 {
+			column := strings.LastIndex(input.Text(), fields[1]) + 1
+			return nil, input.At(column, "a digit from 0 to 9", aoc.Found(fields[1]))
+		}
+
+		vocabulary[fields[0]] = digit
+	}
+
+	return vocabulary, input.Err()
+}
+
+// One state of the automaton: a prefix of at least one token.
+type node struct {
+	next map[byte]int
+	fail int
+	// Tokens that end at this state, including those reached through the
+	// failure links, as indices into Matcher.tokens.
+	outputs []int
+}
+
+type Matcher struct {
+	tokens []string
+	values []int
+	nodes  []node
+}
+
+// Builds the automaton for every token in the vocabularies.
+func NewMatcher(vocabularies ...Vocabulary) *Matcher {
+	matcher := &Matcher{nodes: []node{{next: map[byte]int{}}}}
+
+	for _, vocabulary := range vocabularies {
+		for token, value := range vocabulary {
+			if token == "" {
+				continue
+			}
+
+			state := 0
+			for i := 0; i < len(token); i++ {
+				next, ok := matcher.nodes[state].next[token[i]]
+				if !ok {
+					next = len(matcher.nodes)
+					matcher.nodes = append(matcher.nodes, node{next: map[byte]int{}})
+					matcher.nodes[state].next[token[i]] = next
+				}
+				state = next
+			}
+
+			matcher.nodes[state].outputs = append(matcher.nodes[state].outputs, len(matcher.tokens))
+			matcher.tokens = append(matcher.tokens, token)
+			matcher.values = append(matcher.values, value)
+		}
+	}
+
+	// Breadth first, so each failure link points at a state that is
+	// already complete.
+	queue := []int{}
+	for _, child := range matcher.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for char, child := range matcher.nodes[state].next {
+			fail := matcher.nodes[state].fail
+			for fail > 0 && !matcher.has_edge(fail, char) {
+				fail = matcher.nodes[fail].fail
+			}
+			if next, ok := matcher.nodes[fail].next[char]; ok && next != child {
+				fail = next
+			}
+
+			matcher.nodes[child].fail = fail
+			matcher.nodes[child].outputs = append(matcher.nodes[child].outputs, matcher.nodes[fail].outputs...)
+
+			queue = append(queue, child)
+		}
+	}
+
+	return matcher
+}
+
+func (matcher *Matcher) has_edge(state int, char byte) bool {
+	_, ok := matcher.nodes[state].next[char]
+	return ok
+}
+
+// A token found in a line, starting at byte Start.
+type Match struct {
+	Token string
+	Value int
+	Start int
+}
+
+// Every token occurrence in the line, overlapping ones included, ordered
+// by where they end.
+func (matcher *Matcher) FindAll(str string) []Match {
+	matches := make([]Match, 0)
+	state := 0
+
+	for i := 0; i < len(str); i++ {
+		for state > 0 && !matcher.has_edge(state, str[i]) {
+			state = matcher.nodes[state].fail
+		}
+		if next, ok := matcher.nodes[state].next[str[i]]; ok {
+			state = next
+		}
+
+		for _, output := range matcher.nodes[state].outputs {
+			token := matcher.tokens[output]
+			matches = append(matches, Match{token, matcher.values[output], i + 1 - len(token)})
+		}
+	}
+
+	return matches
+}
+
+// Returns the first and last tokens in the line. When two tokens start at
+// the same place the longer one wins. Fails if the line has no tokens.
+func (matcher *Matcher) FirstAndLast(str string) (Match, Match, error) {
+	matches := matcher.FindAll(str)
+
+	if len(matches) == 0 {
+		return Match{}, Match{}, aoc.Expected(0, "a digit or digit word", "none")
+	}
+
+	first := matches[0]
+	last := matches[0]
+
+	for _, match := range matches[1:] {
+		if match.Start < first.Start || (match.Start == first.Start && len(match.Token) > len(first.Token)) {
+			first = match
+		}
+		if match.Start > last.Start || (match.Start == last.Start && len(match.Token) > len(last.Token)) {
+			last = match
+		}
+	}
+
+	return first, last, nil
+}
+
+// The two digit calibration value: the first digit followed by the last.
+func (matcher *Matcher) Value(str string) (int, error) {
+	first, last, err := matcher.FirstAndLast(str)
+	if err != nil {
+		return -1, err
+	}
+
+	return 10*first.Value + last.Value, nil
+}
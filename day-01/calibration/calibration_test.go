@@ -0,0 +1,132 @@
+package calibration
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func TestValue(t *testing.T) {
+	digits := NewMatcher(Digits)
+	words := NewMatcher(Digits, English)
+
+	tests := []struct {
+		matcher *Matcher
+		line    string
+		want    int
+	}{
+		{digits, "1abc2", 12},
+		{digits, "pqr3stu8vwx", 38},
+		{digits, "a1b2c3d4e5f", 15},
+		{digits, "treb7uchet", 77},
+		{digits, "two1nine", 11},
+		{words, "two1nine", 29},
+		{words, "eightwothree", 83},
+		{words, "abcone2threexyz", 13},
+		{words, "xtwone3four", 24},
+		{words, "4nineeightseven2", 42},
+		{words, "zoneight234", 14},
+		{words, "7pqrstsixteen", 76},
+		// Overlapping words at the end count too.
+		{words, "3twone", 31},
+		{words, "oneight", 18},
+	}
+
+	for _, test := range tests {
+		got, err := test.matcher.Value(test.line)
+		if err != nil {
+			t.Errorf("Value(%q) failed: %v", test.line, err)
+		} else if got != test.want {
+			t.Errorf("Value(%q) = %d, want %d", test.line, got, test.want)
+		}
+	}
+
+	if _, err := digits.Value("abcdef"); err == nil {
+		t.Errorf("Value(%q) found a digit", "abcdef")
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	matcher := NewMatcher(Digits, English)
+
+	matches := matcher.FindAll("eightwone7")
+
+	want := []Match{
+		{"eight", 8, 0},
+		{"two", 2, 4},
+		{"one", 1, 6},
+		{"7", 7, 9},
+	}
+
+	if len(matches) != len(want) {
+		t.Fatalf("FindAll = %+v, want %+v", matches, want)
+	}
+
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("match %d is %+v, want %+v", i, matches[i], want[i])
+		}
+	}
+}
+
+func TestFirstAndLastPrefersLongerTokens(t *testing.T) {
+	// "se" and "seven" both start at 0, "en" and "n" both near the end.
+	matcher := NewMatcher(Vocabulary{"se": 1, "seven": 7, "en": 2, "n": 3})
+
+	first, last, err := matcher.FirstAndLast("seven")
+	if err != nil {
+		t.Fatalf("FirstAndLast failed: %v", err)
+	}
+
+	if first.Token != "seven" || last.Token != "n" {
+		t.Errorf("FirstAndLast = %q, %q, want \"seven\", \"n\"", first.Token, last.Token)
+	}
+}
+
+func TestLoadVocabulary(t *testing.T) {
+	dir := t.TempDir()
+
+	good := filepath.Join(dir, "german.txt")
+	os.WriteFile(good, []byte("# German\neins 1\n\nzwei 2\ndrei 3\n"), 0644)
+
+	vocabulary, err := LoadVocabulary(good)
+	if err != nil {
+		t.Fatalf("LoadVocabulary failed: %v", err)
+	}
+
+	if got, _ := NewMatcher(Digits, vocabulary).Value("xeinsdreizweix"); got != 12 {
+		t.Errorf("Value with German = %d, want 12", got)
+	}
+
+	tests := []struct {
+		text   string
+		line   int
+		column int
+	}{
+		{"eins 1\nzwei\n", 2, 0},
+		{"eins 1\nzwei 2 3\n", 2, 0},
+		{"eins 1\nzwei 12\n", 2, 6},
+		{"eins one\n", 1, 6},
+	}
+
+	for _, test := range tests {
+		bad := filepath.Join(dir, "bad.txt")
+		os.WriteFile(bad, []byte(test.text), 0644)
+
+		_, err := LoadVocabulary(bad)
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) {
+			t.Errorf("LoadVocabulary(%q) = %v, want an input error", test.text, err)
+			continue
+		}
+
+		if input_err.Line != test.line || input_err.Column != test.column {
+			t.Errorf("LoadVocabulary(%q) points at line %d, column %d, want line %d, column %d",
+				test.text, input_err.Line, input_err.Column, test.line, test.column)
+		}
+	}
+}
@@ -1,19 +1,22 @@
 package main
 
-import "fmt"
-import "os"
-import "log"
 import "strconv"
-import "strings"
 import "regexp"
-import "bufio"
+
+import "advent-of-code/aoc"
+
+var parsing = aoc.Trace(aoc.Parse)
 
 var just_numbers_regex = regexp.MustCompile(`[0-9]`)
 
-func single_line(str string) int {
+func single_line(str string) (int, error) {
 	sub_string := just_numbers_regex.FindAllString(str, -1)
 	n_strings := len(sub_string)
 
+	if n_strings == 0 {
+		return 0, aoc.Expected(0, "at least one digit", "none")
+	}
+
 	// Deal with the fact we may have more than one character
 	first := string(sub_string[0])
 	final := first
@@ -22,36 +25,39 @@ func single_line(str string) int {
 		final = string(sub_string[n_strings-1])
 	}
 
-	first_and_last := strings.TrimSpace(
-		first + final,
-	)
-
-	real_int, err := strconv.Atoi(first_and_last)
-
-	if err != nil {
-		log.Fatal(err)
-	}
+	// Both are single digits, so this cannot fail.
+	real_int, _ := strconv.Atoi(first + final)
 
-	return real_int
+	return real_int, nil
 }
 
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
+	aoc.Main(1, 1, solve)
+}
 
-	total := 0
+func solve(run *aoc.Run) {
+	input := run.Input
+
+	values := []int{}
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	for input.Scan() {
+		text := input.Text()
 
-		single := single_line(text)
-		fmt.Println("Output: ", single, " Base: ", text)
+		single, err := single_line(text)
+		aoc.Check(input.Wrap(err))
+		parsing.Debug("Output: ", single, " Base: ", text)
 
-		total += single
+		values = append(values, single)
 	}
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	total := 0
+
+	for _, value := range values {
+		total += value
 	}
 
-	fmt.Println("Total: ", total)
+	run.Answer("Total", total)
 }
@@ -1,86 +1,70 @@
 package main
 
-import "fmt"
-import "os"
-import "log"
-import "regexp"
-import "bufio"
-
-var string_map = map[string]int{
-	"0":     0,
-	"1":     1,
-	"2":     2,
-	"3":     3,
-	"4":     4,
-	"5":     5,
-	"6":     6,
-	"7":     7,
-	"8":     8,
-	"9":     9,
-	"one":   1,
-	"two":   2,
-	"three": 3,
-	"four":  4,
-	"five":  5,
-	"six":   6,
-	"seven": 7,
-	"eight": 8,
-	"nine":  9,
-}
+import "flag"
+import "strings"
 
-func set_regex() *regexp.Regexp {
-	regex := ""
+import "advent-of-code/aoc"
+import "advent-of-code/day-01/calibration"
 
-	for k := range string_map {
-		regex += string(k + "|")
-	}
+var parsing = aoc.Trace(aoc.Parse)
 
-	return regexp.MustCompile(regex[:len(regex)-1])
+// Collects repeated -vocab flags.
+type vocabulary_files []string
+
+func (files *vocabulary_files) String() string {
+	return strings.Join(*files, ",")
 }
 
-var just_numbers_regex = set_regex()
+func (files *vocabulary_files) Set(value string) error {
+	*files = append(*files, value)
+	return nil
+}
 
-func single_line_multimatch(str string) int {
-	// First match is super easy.
-	first_match := string_map[just_numbers_regex.FindString(str)]
-	final_match := -1
+var files = vocabulary_files{}
+
+func main() {
+	flag.Var(&files, "vocab", "file of \"<token> <digit>\" lines to use instead of the English words; may be repeated")
+	aoc.Main(1, 2, solve)
+}
 
-	for i := len(str); i >= 0; i-- {
-		match := just_numbers_regex.FindString(str[i:])
+func solve(run *aoc.Run) {
+	vocabularies := []calibration.Vocabulary{calibration.Digits}
 
-		if match != "" {
-			// First match going backwards!
-			final_match = string_map[match]
-			break
-		}
+	if len(files) == 0 {
+		vocabularies = append(vocabularies, calibration.English)
 	}
 
-	// Combine back together
-	return 10*first_match + final_match
-}
+	for _, path := range files {
+		vocabulary, err := calibration.LoadVocabulary(path)
+		aoc.Check(err)
+		vocabularies = append(vocabularies, vocabulary)
+	}
 
-func main() {
-	DEBUG := false
+	matcher := calibration.NewMatcher(vocabularies...)
 
-	scanner := bufio.NewScanner(os.Stdin)
+	input := run.Input
 
-	total := 0
+	values := []int{}
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	for input.Scan() {
+		text := input.Text()
 
-		single := single_line_multimatch(text)
+		single, err := matcher.Value(text)
+		aoc.Check(input.Wrap(err))
 
-		if DEBUG {
-			fmt.Println("Output: ", single, " Base: ", text)
-		}
+		parsing.Debug("Output: ", single, " Base: ", text)
 
-		total += single
+		values = append(values, single)
 	}
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	total := 0
+
+	for _, value := range values {
+		total += value
 	}
 
-	fmt.Println("Total: ", total)
+	run.Answer("Total", total)
 }
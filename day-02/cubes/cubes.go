@@ -0,0 +1,268 @@
+// Package cubes parses the cube games from day 2 without assuming which
+// colours exist, and checks them against any number of bags.
+package cubes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"advent-of-code/aoc"
+)
+
+// How many cubes of each colour were drawn (or are in a bag).
+type Draw map[string]int
+
+type Game struct {
+	ID    int
+	Draws []Draw
+}
+
+type Bag struct {
+	Name   string
+	Limits Draw
+}
+
+// Why a game could not be played with a bag: draw number Draw (0-indexed)
+// needed Count cubes of Colour, but the bag only has Limit.
+type Violation struct {
+	Draw   int
+	Colour string
+	Count  int
+	Limit  int
+}
+
+func (violation Violation) String() string {
+	return fmt.Sprintf("draw %d has %d %s, the bag %d", violation.Draw+1, violation.Count, violation.Colour, violation.Limit)
+}
+
+var game_regex = regexp.MustCompile(`^\s*Game\s+(\d+)\s*:(.*)$`)
+var cubes_regex = regexp.MustCompile(`^\s*(\d+)\s+(\S+)\s*$`)
+
+// Parses a comma separated list like "3 blue, 4 red".
+func ParseDraw(str string) (Draw, error) {
+	draw := Draw{}
+
+	if strings.TrimSpace(str) == "" {
+		return draw, nil
+	}
+
+	column := 1
+
+	for _, part := range strings.Split(str, ",") {
+		matches := cubes_regex.FindStringSubmatchIndex(part)
+
+		if matches == nil {
+			start := column + len(part) - len(strings.TrimLeft(part, " "))
+			return nil, aoc.Expected(start, "\"<count> <colour>\"", aoc.Found(strings.TrimSpace(part)))
+		}
+
+		count, err := strconv.Atoi(part[matches[2]:matches[3]])
+		if err != nil {
+			return nil, aoc.Expected(column+matches[2], "a count that fits in an int", aoc.Found(part[matches[2]:matches[3]]))
+		}
+
+		draw[part[matches[4]:matches[5]]] += count
+
+		column += len(part) + 1
+	}
+
+	return draw, nil
+}
+
+func ParseGame(str string) (Game, error) {
+	matches := game_regex.FindStringSubmatchIndex(str)
+
+	if matches == nil {
+		return Game{}, aoc.Expected(1, "\"Game <id>: <draws>\"", aoc.Found(str))
+	}
+
+	id, err := strconv.Atoi(str[matches[2]:matches[3]])
+	if err != nil {
+		return Game{}, aoc.Expected(matches[2]+1, "a game id that fits in an int", aoc.Found(str[matches[2]:matches[3]]))
+	}
+
+	game := Game{ID: id}
+	column := matches[4]
+
+	for _, draw_string := range strings.Split(str[matches[4]:], ";") {
+		draw, err := ParseDraw(draw_string)
+		if err != nil {
+			return Game{}, aoc.Shift(err, column)
+		}
+
+		game.Draws = append(game.Draws, draw)
+		column += len(draw_string) + 1
+	}
+
+	return game, nil
+}
+
+// Parses a bag like "12 red, 13 green, 14 blue", optionally preceded by a
+// name: "small: 2 red, 1 blue".
+func ParseBag(str string) (Bag, error) {
+	bag := Bag{Name: strings.TrimSpace(str)}
+	offset := 0
+
+	if name, limits, found := strings.Cut(str, ":"); found {
+		bag.Name = strings.TrimSpace(name)
+		str = limits
+		offset = len(name) + 1
+	}
+
+	limits, err := ParseDraw(str)
+	if err != nil {
+		return Bag{}, aoc.Shift(err, offset)
+	}
+
+	bag.Limits = limits
+
+	return bag, nil
+}
+
+// Reads one bag per non-empty line; lines starting with # are ignored.
+func LoadBags(path string) ([]Bag, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	bags := make([]Bag, 0)
+	input := aoc.NewInput(file, path)
+
+	for input.Scan() {
+		text := input.Text()
+
+		if trimmed := strings.TrimSpace(text); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		bag, err := ParseBag(text)
+		if err != nil {
+			return nil, input.Wrap(err)
+		}
+
+		bags = append(bags, bag)
+	}
+
+	return bags, input.Err()
+}
+
+// The colours in the draw, sorted so output is stable.
+func (draw Draw) Colours() []string {
+	colours := make([]string, 0, len(draw))
+
+	for colour := range draw {
+		colours = append(colours, colour)
+	}
+
+	sort.Strings(colours)
+
+	return colours
+}
+
+func (draw Draw) String() string {
+	parts := make([]string, 0, len(draw))
+
+	for _, colour := range draw.Colours() {
+		parts = append(parts, fmt.Sprintf("%d %s", draw[colour], colour))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Returns the first draw that needs more cubes of some colour than the bag
+// holds; colours the bag does not mention count as zero. The game is
+// possible if there is none.
+func (game Game) Check(bag Bag) (Violation, bool) {
+	for i, draw := range game.Draws {
+		for _, colour := range draw.Colours() {
+			if draw[colour] > bag.Limits[colour] {
+				return Violation{i, colour, draw[colour], bag.Limits[colour]}, false
+			}
+		}
+	}
+
+	return Violation{}, true
+}
+
+// The smallest bag the game could have been played with. Every colour in
+// colours is included, so a colour the game never drew shows up as zero.
+func (game Game) MinimumPossible(colours []string) Draw {
+	balls_in_bag := Draw{}
+
+	for _, colour := range colours {
+		balls_in_bag[colour] = 0
+	}
+
+	for _, draw := range game.Draws {
+		for colour, count := range draw {
+			balls_in_bag[colour] = max(balls_in_bag[colour], count)
+		}
+	}
+
+	return balls_in_bag
+}
+
+// The product of the number of cubes of each colour.
+func (draw Draw) Power() int {
+	power := 1
+
+	for _, count := range draw {
+		power *= count
+	}
+
+	return power
+}
+
+// Every colour drawn in any of the games.
+func AllColours(games []Game) []string {
+	all := Draw{}
+
+	for _, game := range games {
+		for _, draw := range game.Draws {
+			for colour := range draw {
+				all[colour] = 0
+			}
+		}
+	}
+
+	return all.Colours()
+}
+
+// Writes a row per game saying whether each bag could have played it,
+// and if not which draw ruled it out, followed by the game's minimum bag
+// and that bag's power.
+func Report(w io.Writer, games []Game, bags []Bag) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	colours := AllColours(games)
+
+	fmt.Fprint(table, "game")
+	for _, bag := range bags {
+		fmt.Fprintf(table, "\t%s", bag.Name)
+	}
+	fmt.Fprintln(table, "\tminimum bag\tpower")
+
+	for _, game := range games {
+		fmt.Fprint(table, game.ID)
+
+		for _, bag := range bags {
+			if violation, possible := game.Check(bag); possible {
+				fmt.Fprint(table, "\tpossible")
+			} else {
+				fmt.Fprintf(table, "\timpossible: %s", violation)
+			}
+		}
+
+		minimum := game.MinimumPossible(colours)
+		fmt.Fprintf(table, "\t%s\t%d\n", minimum, minimum.Power())
+	}
+
+	return table.Flush()
+}
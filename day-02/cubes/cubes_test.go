@@ -0,0 +1,205 @@
+package cubes
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+var example = []string{
+	"Game 1: 3 blue, 4 red; 1 red, 2 green, 6 blue; 2 green",
+	"Game 2: 1 blue, 2 green; 3 green, 4 blue, 1 red; 1 green, 1 blue",
+	"Game 3: 8 green, 6 blue, 20 red; 5 blue, 4 red, 13 green; 5 green, 1 red",
+	"Game 4: 1 green, 3 red, 6 blue; 3 green, 6 red; 3 green, 15 blue, 14 red",
+	"Game 5: 6 red, 1 blue, 3 green; 2 blue, 1 red, 2 green",
+}
+
+func parse_games(t *testing.T) []Game {
+	t.Helper()
+
+	games := make([]Game, len(example))
+
+	for i, line := range example {
+		game, err := ParseGame(line)
+		if err != nil {
+			t.Fatalf("ParseGame(%q) failed: %v", line, err)
+		}
+		games[i] = game
+	}
+
+	return games
+}
+
+func TestParseDraw(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"3 blue, 4 red", "3 blue, 4 red"},
+		{"", ""},
+		{"  ", ""},
+		// Colours need not be the usual three, and repeats add up.
+		{"1 teal, 2 teal, 5 mauve", "5 mauve, 3 teal"},
+	}
+
+	for _, test := range tests {
+		draw, err := ParseDraw(test.text)
+		if err != nil {
+			t.Errorf("ParseDraw(%q) failed: %v", test.text, err)
+		} else if draw.String() != test.want {
+			t.Errorf("ParseDraw(%q) = %q, want %q", test.text, draw, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text   string
+		column int
+	}{
+		{"Round 1: 3 blue", 1},
+		{"Game 99999999999999999999: 3 blue", 6},
+		{"Game 1: 3 blue, four red", 17},
+		{"Game 1: 3 blue; 4 red, 2", 24},
+		{"Game 1: 99999999999999999999 blue", 9},
+	}
+
+	for _, test := range tests {
+		_, err := ParseGame(test.text)
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) {
+			t.Errorf("ParseGame(%q) = %v, want an input error", test.text, err)
+			continue
+		}
+
+		if input_err.Column != test.column {
+			t.Errorf("ParseGame(%q) points at column %d, want %d", test.text, input_err.Column, test.column)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	bag := Bag{"puzzle", Draw{"red": 12, "green": 13, "blue": 14}}
+
+	tests := []struct {
+		game      int
+		possible  bool
+		violation Violation
+	}{
+		{0, true, Violation{}},
+		{1, true, Violation{}},
+		{2, false, Violation{0, "red", 20, 12}},
+		{3, false, Violation{2, "blue", 15, 14}},
+		{4, true, Violation{}},
+	}
+
+	games := parse_games(t)
+
+	for _, test := range tests {
+		violation, possible := games[test.game].Check(bag)
+
+		if possible != test.possible || violation != test.violation {
+			t.Errorf("game %d: Check = %+v, %v, want %+v, %v", test.game+1, violation, possible, test.violation, test.possible)
+		}
+	}
+
+	// A colour the bag does not mention counts as none.
+	if _, possible := games[0].Check(Bag{"no green", Draw{"red": 12, "blue": 14}}); possible {
+		t.Errorf("game 1 is possible without green cubes")
+	}
+}
+
+func TestMinimumPossible(t *testing.T) {
+	tests := []struct {
+		game  int
+		want  string
+		power int
+	}{
+		{0, "6 blue, 2 green, 4 red", 48},
+		{1, "4 blue, 3 green, 1 red", 12},
+		{2, "6 blue, 13 green, 20 red", 1560},
+		{3, "15 blue, 3 green, 14 red", 630},
+		{4, "2 blue, 3 green, 6 red", 36},
+	}
+
+	games := parse_games(t)
+	colours := AllColours(games)
+
+	if !slices.Equal(colours, []string{"blue", "green", "red"}) {
+		t.Fatalf("AllColours = %v, want [blue green red]", colours)
+	}
+
+	for _, test := range tests {
+		minimum := games[test.game].MinimumPossible(colours)
+
+		if minimum.String() != test.want || minimum.Power() != test.power {
+			t.Errorf("game %d: MinimumPossible = %q with power %d, want %q with power %d",
+				test.game+1, minimum, minimum.Power(), test.want, test.power)
+		}
+	}
+
+	// A colour the game never drew makes the power zero.
+	if power := games[0].MinimumPossible([]string{"blue", "green", "red", "teal"}).Power(); power != 0 {
+		t.Errorf("power with an undrawn colour = %d, want 0", power)
+	}
+}
+
+func TestReport(t *testing.T) {
+	games := parse_games(t)[1:3]
+	bags := []Bag{{"puzzle", Draw{"red": 12, "green": 13, "blue": 14}}, {"none", Draw{}}}
+
+	output := strings.Builder{}
+	if err := Report(&output, games, bags); err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	want := `game  puzzle                                     none                                      minimum bag               power
+2     possible                                   impossible: draw 1 has 1 blue, the bag 0  4 blue, 3 green, 1 red    12
+3     impossible: draw 1 has 20 red, the bag 12  impossible: draw 1 has 6 blue, the bag 0  6 blue, 13 green, 20 red  1560
+`
+
+	if output.String() != want {
+		t.Errorf("Report wrote\n%s\nwant\n%s", output.String(), want)
+	}
+}
+
+func TestLoadBags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bags.txt")
+	os.WriteFile(path, []byte("# The puzzle's bag\npuzzle: 12 red, 13 green, 14 blue\n\n2 red, 1 blue\n"), 0644)
+
+	bags, err := LoadBags(path)
+	if err != nil {
+		t.Fatalf("LoadBags failed: %v", err)
+	}
+
+	if len(bags) != 2 {
+		t.Fatalf("LoadBags found %d bags, want 2", len(bags))
+	}
+
+	if bags[0].Name != "puzzle" || bags[0].Limits.String() != "14 blue, 13 green, 12 red" {
+		t.Errorf("first bag is %q: %q", bags[0].Name, bags[0].Limits)
+	}
+
+	if bags[1].Name != "2 red, 1 blue" || bags[1].Limits["red"] != 2 {
+		t.Errorf("second bag is %q: %q", bags[1].Name, bags[1].Limits)
+	}
+
+	os.WriteFile(path, []byte("puzzle: 12 red\nsmall: 2 red, lots blue\n"), 0644)
+
+	_, err = LoadBags(path)
+
+	input_err := &aoc.InputError{}
+	if !errors.As(err, &input_err) {
+		t.Fatalf("LoadBags = %v, want an input error", err)
+	}
+
+	if input_err.Line != 2 || input_err.Column != 15 {
+		t.Errorf("LoadBags points at line %d, column %d, want line 2, column 15", input_err.Line, input_err.Column)
+	}
+}
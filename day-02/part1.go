@@ -1,118 +1,99 @@
 package main
 
 import "fmt"
+import "flag"
 import "os"
-import "log"
-import "regexp"
-import "bufio"
-import "strconv"
-import "strings"
-
-var blue_regex = regexp.MustCompile(`(\d*) blue`)
-var red_regex = regexp.MustCompile(`(\d*) red`)
-var green_regex = regexp.MustCompile(`(\d*) green`)
-var game_regex = regexp.MustCompile(`Game (\d*)`)
-
-type ReplacementResult struct {
-	red   int
-	green int
-	blue  int
-}
 
-type GameResult struct {
-	replacements []ReplacementResult
-	id           int
-}
+import "advent-of-code/aoc"
+import "advent-of-code/day-02/cubes"
 
-var best_possible_replacement = ReplacementResult{red: 12, green: 13, blue: 14}
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
 
-func parse_game(str string) GameResult {
-	game_string := game_regex.FindStringSubmatch(str)
-	id, _ := strconv.Atoi(game_string[1])
+var best_possible_replacement = "12 red, 13 green, 14 blue"
 
-	replacement_strings := strings.Split(str, ";")
-	replacements := make([]ReplacementResult, len(replacement_strings))
+// Collects repeated -bag flags.
+type bag_list []cubes.Bag
 
-	for i := range replacement_strings {
-		replacements[i] = parse_replacement(replacement_strings[i])
+func (bags *bag_list) String() string {
+	return fmt.Sprint(*bags)
+}
+
+func (bags *bag_list) Set(value string) error {
+	bag, err := cubes.ParseBag(value)
+	if err != nil {
+		return err
 	}
 
-	return GameResult{replacements: replacements, id: id}
+	*bags = append(*bags, bag)
+
+	return nil
 }
 
-func parse_replacement(str string) ReplacementResult {
-	red_string := red_regex.FindStringSubmatch(str)
-	blue_string := blue_regex.FindStringSubmatch(str)
-	green_string := green_regex.FindStringSubmatch(str)
+var bag_flags = bag_list{}
+var bags_path = flag.String("bags", "", "file with one bag per line")
+var report = flag.Bool("report", false, "write whether each game is possible with each bag, and its minimum bag and power, on stderr")
 
-	result := ReplacementResult{red: 0, green: 0, blue: 0}
+func main() {
+	flag.Var(&bag_flags, "bag", "bag to check against, e.g. \"small: 2 red, 1 blue\"; may be repeated (default \""+best_possible_replacement+"\")")
+	aoc.Main(2, 1, solve)
+}
 
-	if len(red_string) > 0 {
-		result.red, _ = strconv.Atoi(red_string[1])
-	}
+func solve(run *aoc.Run) {
+	bags := append(bag_list{}, bag_flags...)
 
-	if len(blue_string) > 0 {
-		result.blue, _ = strconv.Atoi(blue_string[1])
+	if *bags_path != "" {
+		loaded, err := cubes.LoadBags(*bags_path)
+		aoc.Check(err)
+		bags = append(bags, loaded...)
 	}
 
-	if len(green_string) > 0 {
-		result.green, _ = strconv.Atoi(green_string[1])
+	if len(bags) == 0 {
+		bags.Set(best_possible_replacement)
 	}
 
-	return result
-}
+	input := run.Input
 
-func is_possible(results []ReplacementResult) bool {
-	game_possible := true
+	games := make([]cubes.Game, 0)
 
-	for i := range results {
-		current_result := results[i]
+	for input.Scan() {
+		text := input.Text()
 
-		if current_result.red > best_possible_replacement.red {
-			game_possible = false
-			break
-		}
+		game_result, err := cubes.ParseGame(text)
+		aoc.Check(input.Wrap(err))
 
-		if current_result.green > best_possible_replacement.green {
-			game_possible = false
-			break
-		}
+		parsing.Debug("Output: ", game_result, " Base: ", text)
 
-		if current_result.blue > best_possible_replacement.blue {
-			game_possible = false
-			break
-		}
+		games = append(games, game_result)
 	}
 
-	return game_possible
-}
-
-func main() {
-	DEBUG := true
-
-	scanner := bufio.NewScanner(os.Stdin)
+	aoc.Check(input.Err())
+	run.Parsed()
 
-	total := 0
+	if *report {
+		done := run.Time(aoc.Render)
+		aoc.Check(cubes.Report(os.Stderr, games, bags))
+		done()
+	}
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	for _, bag := range bags {
+		total := 0
 
-		game_result := parse_game(text)
-		game_possible := is_possible(game_result.replacements)
+		for _, game := range games {
+			violation, game_possible := game.Check(bag)
 
-		if DEBUG {
-			fmt.Println("Output: ", game_result, " Base: ", text)
-			fmt.Println("Game was possible: ", game_possible)
+			if game_possible {
+				total += game.ID
+			} else {
+				solving.Infof("Game %d is impossible with bag %q: draw %d has %d %s, the bag has %d",
+					game.ID, bag.Name, violation.Draw+1, violation.Count, violation.Colour, violation.Limit)
+			}
 		}
 
-		if game_possible {
-			total += game_result.id
+		if len(bags) == 1 {
+			run.Answer("Total", total)
+		} else {
+			run.Answer(fmt.Sprintf("Total (%s)", bag.Name), total)
 		}
 	}
-
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
-	}
-
-	fmt.Println("Total: ", total)
 }
@@ -1,121 +1,60 @@
 package main
 
-import "fmt"
+import "flag"
 import "os"
-import "log"
-import "regexp"
-import "bufio"
-import "strconv"
-import "strings"
-
-var blue_regex = regexp.MustCompile(`(\d*) blue`)
-var red_regex = regexp.MustCompile(`(\d*) red`)
-var green_regex = regexp.MustCompile(`(\d*) green`)
-var game_regex = regexp.MustCompile(`Game (\d*)`)
-
-type ReplacementResult struct {
-	red   int
-	green int
-	blue  int
-}
-
-type GameResult struct {
-	replacements []ReplacementResult
-	id           int
-}
 
-func parse_game(str string) GameResult {
-	game_string := game_regex.FindStringSubmatch(str)
-	id, _ := strconv.Atoi(game_string[1])
+import "advent-of-code/aoc"
+import "advent-of-code/day-02/cubes"
 
-	replacement_strings := strings.Split(str, ";")
-	replacements := make([]ReplacementResult, len(replacement_strings))
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
 
-	for i := range replacement_strings {
-		replacements[i] = parse_replacement(replacement_strings[i])
-	}
+var report = flag.Bool("report", false, "write each game's minimum bag and power on stderr")
 
-	return GameResult{replacements: replacements, id: id}
+func main() {
+	aoc.Main(2, 2, solve)
 }
 
-func parse_replacement(str string) ReplacementResult {
-	red_string := red_regex.FindStringSubmatch(str)
-	blue_string := blue_regex.FindStringSubmatch(str)
-	green_string := green_regex.FindStringSubmatch(str)
-
-	result := ReplacementResult{red: 0, green: 0, blue: 0}
+func solve(run *aoc.Run) {
+	input := run.Input
 
-	if len(red_string) > 0 {
-		result.red, _ = strconv.Atoi(red_string[1])
-	}
-
-	if len(blue_string) > 0 {
-		result.blue, _ = strconv.Atoi(blue_string[1])
-	}
-
-	if len(green_string) > 0 {
-		result.green, _ = strconv.Atoi(green_string[1])
-	}
-
-	return result
-}
+	games := make([]cubes.Game, 0)
 
-func minimum_possible(results []ReplacementResult) ReplacementResult {
-	balls_in_bag := ReplacementResult{red: 0, green: 0, blue: 0}
+	for input.Scan() {
+		text := input.Text()
 
-	for i := range results {
-		current_result := results[i]
+		game_result, err := cubes.ParseGame(text)
+		aoc.Check(input.Wrap(err))
 
-		if current_result.red > balls_in_bag.red {
-			balls_in_bag.red = current_result.red
-		}
+		parsing.Debug("Output: ", game_result, " Base: ", text)
 
-		if current_result.green > balls_in_bag.green {
-			balls_in_bag.green = current_result.green
-		}
-
-		if current_result.blue > balls_in_bag.blue {
-			balls_in_bag.blue = current_result.blue
-		}
+		games = append(games, game_result)
 	}
 
-	return balls_in_bag
-}
-
-func bag_power(result ReplacementResult) int {
-	return result.red * result.green * result.blue
-}
-
-func minimum_game_power(result GameResult) int {
-	balls_in_bag := minimum_possible(result.replacements)
-
-	return bag_power(balls_in_bag)
-}
+	aoc.Check(input.Err())
+	run.Parsed()
 
-func main() {
-	DEBUG := true
+	if *report {
+		done := run.Time(aoc.Render)
+		aoc.Check(cubes.Report(os.Stderr, games, nil))
+		done()
+	}
 
-	scanner := bufio.NewScanner(os.Stdin)
+	// A colour that a game never drew still counts (as zero) towards its
+	// power, as long as some other game drew it.
+	colours := cubes.AllColours(games)
 
 	total := 0
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	for _, game := range games {
+		minimum_bag := game.MinimumPossible(colours)
+		game_power := minimum_bag.Power()
 
-		game_result := parse_game(text)
-		game_power := minimum_game_power(game_result)
-
-		if DEBUG {
-			fmt.Println("Output: ", game_result, " Base: ", text)
-			fmt.Println("Game minimum power: ", game_power)
-		}
+		solving.Debug("Game", game.ID, "minimum bag: ", minimum_bag)
+		solving.Debug("Game minimum power: ", game_power)
 
 		total += game_power
 	}
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
-	}
-
-	fmt.Println("Total: ", total)
+	run.Answer("Total", total)
 }
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func BenchmarkPart2(b *testing.B) {
+	aoc.Benchmark(b, 2, 2, "real.txt", solve)
+}
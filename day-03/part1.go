@@ -2,151 +2,71 @@ package main
 
 import "fmt"
 import "os"
-import "log"
-import "regexp"
-import "bufio"
-import "strconv"
+import "flag"
 
-//import "strings"
+import "advent-of-code/aoc"
+import "advent-of-code/day-03/schematic"
 
-var number_regex = regexp.MustCompile(`\d*`)
-var token_regex = regexp.MustCompile(`[^\.|\d|\n]`)
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
 
-func parse_line(str string, mapping map[int]int, current_uid int) ([]int, []bool, map[int]int, int) {
-	number_of_characters := len(str)
+var class = flag.String("symbols", "", "only count numbers next to these symbols (default: any symbol)")
+var orphans = flag.Bool("orphans", false, "list the numbers that are not adjacent to any symbol on stderr")
+var lint = flag.Bool("lint", false, "check the schematic and draw which numbers were counted, on stderr")
 
-	ids := make([]int, number_of_characters)
-	tokens := make([]bool, number_of_characters)
-
-	// First parse tokens, much easier.
-	indicies := token_regex.FindAllStringIndex(str, -1)
-
-	for _, index := range indicies {
-		tokens[index[0]] = true
-	}
-
-	// Ids a bit more complex, requires both indicies and values
-	values := number_regex.FindAllString(str, -1)
-	indicies = number_regex.FindAllStringIndex(str, -1)
-
-	for i := 0; i < len(values); i++ {
-		value := values[i]
-		index := indicies[i][0]
-		// Use a UID not a part number because they may appear twice!
-		current_uid++
-		converted_value, _ := strconv.Atoi(value)
-		mapping[current_uid] = converted_value
-
-		// Loop over n, where n is string length
-		for x := 0; x < len(value); x++ {
-			ids[index+x] = current_uid
-		}
-	}
-
-	return ids, tokens, mapping, current_uid
+func main() {
+	aoc.Main(3, 1, solve)
 }
 
-func extract_part_numbers(tokens [][]bool, values [][]int, mapping map[int]int) []int {
-	m := make(map[int]bool)
-
-	number_of_lines := len(tokens)
-	number_of_columns := len(tokens[0])
-
-	for line_index, token_line := range tokens {
-		// Valid part numbers are 'around' the token.
-		for column_index, token_value := range token_line {
-			if !token_value {
-				continue
-			}
-
-			start_line := max(min(number_of_lines-1, line_index-1), 0)
-			end_line := max(min(number_of_lines-1, line_index+1), 0)
-
-			start_column := max(min(number_of_columns-1, column_index-1), 0)
-			end_column := max(min(number_of_columns-1, column_index+1), 0)
-
-			for line := start_line; line <= end_line; line++ {
-				for column := start_column; column <= end_column; column++ {
-					this_value := values[line][column]
-					if this_value > 0 {
-						m[this_value] = true
-					}
-				}
-			}
-		}
-	}
+func solve(run *aoc.Run) {
+	input := run.Input
 
-	// Now have full map; extract
-	MAX_PART_NUMBER := 100000
+	raw_input, err := input.Lines()
+	aoc.Check(err)
 
-	part_numbers := make([]int, 0)
+	engine, err := schematic.Parse(raw_input)
+	aoc.Check(input.Wrap(err))
+	run.Parsed()
 
-	for i := 0; i <= MAX_PART_NUMBER; i++ {
-		if m[i] {
-			part_numbers = append(part_numbers, mapping[i])
+	if parsing.Enabled(aoc.Debug) {
+		parsing.Debug("Raw:")
+		for _, v := range raw_input {
+			parsing.Debug(v)
 		}
-	}
 
-	return part_numbers
-}
-
-func main() {
-	DEBUG := true
+		parsing.Debug("Symbols:")
+		parsing.Debug(engine.Symbols)
 
-	scanner := bufio.NewScanner(os.Stdin)
-
-	raw_input := make([]string, 0)
-
-	for scanner.Scan() {
-		text := scanner.Text()
-
-		raw_input = append(raw_input, text)
-	}
-
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
-	}
-
-	number_of_lines := len(raw_input)
-
-	tokens := make([][]bool, number_of_lines)
-	values := make([][]int, number_of_lines)
-	mapping := make(map[int]int)
-	current_uid := 1
-
-	for i, l := range raw_input {
-		values[i], tokens[i], mapping, current_uid = parse_line(l, mapping, current_uid)
+		parsing.Debug("Numbers:")
+		parsing.Debug(engine.Numbers)
 	}
 
-	if DEBUG {
-		fmt.Println("Raw:")
-		for _, v := range raw_input {
-			fmt.Println(v)
-		}
+	part_numbers := engine.PartNumbers(*class)
 
-		fmt.Println("Tokens:")
-		for _, v := range tokens {
-			fmt.Println(v)
-		}
+	solving.Debug("Part numbers: ")
+	solving.Debug(part_numbers)
 
-		fmt.Println("Values:")
-		for _, v := range values {
-			fmt.Println(v)
+	if *lint {
+		for _, problem := range engine.Lint() {
+			fmt.Fprintln(os.Stderr, "Warning:", problem)
 		}
+		done := run.Time(aoc.Render)
+		engine.Render(os.Stderr, part_numbers)
+		done()
 	}
 
-	part_numbers := extract_part_numbers(tokens, values, mapping)
-
-	if DEBUG {
-		fmt.Println("Part numbers: ")
-		fmt.Println(part_numbers)
+	if *orphans {
+		fmt.Fprintln(os.Stderr, "Orphans:")
+		for _, number := range engine.Orphans() {
+			fmt.Fprintf(os.Stderr, "  %d at line %d, column %d\n", number.Value, number.Row+1, number.Start+1)
+		}
 	}
 
 	total_part_numbers := 0
 
 	for _, v := range part_numbers {
-		total_part_numbers += v
+		total_part_numbers += v.Value
 	}
 
-	fmt.Println("Total of part numbers: ", total_part_numbers)
+	run.Answer("Total of part numbers", total_part_numbers)
 }
@@ -2,138 +2,105 @@ package main
 
 import "fmt"
 import "os"
-import "log"
-import "regexp"
-import "bufio"
-import "strconv"
-import "slices"
+import "flag"
 
-//import "strings"
+import "advent-of-code/aoc"
+import "advent-of-code/day-03/schematic"
 
-var number_regex = regexp.MustCompile(`\d*`)
-var token_regex = regexp.MustCompile(`\*`)
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
 
-func parse_line(str string, mapping map[int]int, current_uid int) ([]int, []bool, map[int]int, int) {
-	number_of_characters := len(str)
+// Collects repeated -gear flags.
+type gear_rules []schematic.GearRule
 
-	ids := make([]int, number_of_characters)
-	tokens := make([]bool, number_of_characters)
-
-	// First parse tokens, much easier.
-	indicies := token_regex.FindAllStringIndex(str, -1)
+func (rules *gear_rules) String() string {
+	return fmt.Sprint(*rules)
+}
 
-	for _, index := range indicies {
-		tokens[index[0]] = true
+func (rules *gear_rules) Set(value string) error {
+	rule, err := schematic.ParseGearRule(value)
+	if err != nil {
+		return err
 	}
 
-	// Ids a bit more complex, requires both indicies and values
-	values := number_regex.FindAllString(str, -1)
-	indicies = number_regex.FindAllStringIndex(str, -1)
-
-	for i := 0; i < len(values); i++ {
-		value := values[i]
-		index := indicies[i][0]
-		// Use a UID not a part number because they may appear twice!
-		current_uid++
-		converted_value, _ := strconv.Atoi(value)
-		mapping[current_uid] = converted_value
-
-		// Loop over n, where n is string length
-		for x := 0; x < len(value); x++ {
-			ids[index+x] = current_uid
-		}
-	}
+	*rules = append(*rules, rule)
 
-	return ids, tokens, mapping, current_uid
+	return nil
 }
 
-func extract_gear_ratios(tokens [][]bool, values [][]int, mapping map[int]int) int {
-	total_gear_ratios := 0
-
-	number_of_lines := len(tokens)
-	number_of_columns := len(tokens[0])
-
-	for line_index, token_line := range tokens {
-		// Valid part numbers are 'around' the token.
-		for column_index, token_value := range token_line {
-			if !token_value {
-				continue
-			}
-
-			these_mappings := make([]int, 0)
+var rule_flags = gear_rules{}
+var lint = flag.Bool("lint", false, "check the schematic and draw which numbers were counted, on stderr")
 
-			start_line := max(min(number_of_lines-1, line_index-1), 0)
-			end_line := max(min(number_of_lines-1, line_index+1), 0)
-
-			start_column := max(min(number_of_columns-1, column_index-1), 0)
-			end_column := max(min(number_of_columns-1, column_index+1), 0)
+func main() {
+	flag.Var(&rule_flags, "gear", "gear rule such as \"#>=3:sum\"; may be repeated (default \"*=2:product\")")
+	aoc.Main(3, 2, solve)
+}
 
-			for line := start_line; line <= end_line; line++ {
-				for column := start_column; column <= end_column; column++ {
-					this_value := values[line][column]
-					if this_value > 0 {
-						if !(slices.Contains(these_mappings, this_value)) {
-							these_mappings = append(these_mappings, this_value)
-						}
-					}
-				}
-			}
+func solve(run *aoc.Run) {
+	rules := append(gear_rules{}, rule_flags...)
 
-			if len(these_mappings) == 2 {
-				total_gear_ratios += mapping[these_mappings[0]] * mapping[these_mappings[1]]
-			}
-		}
+	if len(rules) == 0 {
+		rules.Set("*=2:product")
 	}
 
-	return total_gear_ratios
-}
-
-func main() {
-	DEBUG := true
+	input := run.Input
 
-	scanner := bufio.NewScanner(os.Stdin)
+	raw_input, err := input.Lines()
+	aoc.Check(err)
 
-	raw_input := make([]string, 0)
+	engine, err := schematic.Parse(raw_input)
+	aoc.Check(input.Wrap(err))
+	run.Parsed()
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	if parsing.Enabled(aoc.Debug) {
+		parsing.Debug("Raw:")
+		for _, v := range raw_input {
+			parsing.Debug(v)
+		}
 
-		raw_input = append(raw_input, text)
-	}
+		parsing.Debug("Symbols:")
+		parsing.Debug(engine.Symbols)
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
+		parsing.Debug("Numbers:")
+		parsing.Debug(engine.Numbers)
 	}
 
-	number_of_lines := len(raw_input)
-
-	tokens := make([][]bool, number_of_lines)
-	values := make([][]int, number_of_lines)
-	mapping := make(map[int]int)
-	current_uid := 1
+	if *lint {
+		counted := make([]schematic.Number, 0)
+		for _, rule := range rules {
+			for _, gear := range engine.Gears(rule) {
+				counted = append(counted, gear.Parts...)
+			}
+		}
 
-	for i, l := range raw_input {
-		values[i], tokens[i], mapping, current_uid = parse_line(l, mapping, current_uid)
+		for _, problem := range engine.Lint(rules...) {
+			fmt.Fprintln(os.Stderr, "Warning:", problem)
+		}
+		done := run.Time(aoc.Render)
+		engine.Render(os.Stderr, counted)
+		done()
 	}
 
-	if DEBUG {
-		fmt.Println("Raw:")
-		for _, v := range raw_input {
-			fmt.Println(v)
+	for _, rule := range rules {
+		gears := engine.Gears(rule)
+
+		if solving.Enabled(aoc.Debug) {
+			solving.Debug("Gears for", rule.Text, ":")
+			for _, gear := range gears {
+				solving.Debug(gear)
+			}
 		}
 
-		fmt.Println("Tokens:")
-		for _, v := range tokens {
-			fmt.Println(v)
+		gear_ratio := 0
+
+		for _, gear := range gears {
+			gear_ratio += gear.Ratio
 		}
 
-		fmt.Println("Values:")
-		for _, v := range values {
-			fmt.Println(v)
+		if len(rules) == 1 {
+			run.Answer("Gear ratio", gear_ratio)
+		} else {
+			run.Answer(fmt.Sprintf("Gear ratio (%s)", rule.Text), gear_ratio)
 		}
 	}
-
-	gear_ratio := extract_gear_ratios(tokens, values, mapping)
-
-	fmt.Println("Gear ratio: ", gear_ratio)
 }
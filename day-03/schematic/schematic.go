@@ -0,0 +1,421 @@
+// Package schematic models the engine schematic from day 3: every number
+// and symbol is indexed with its position, so adjacency can be queried
+// from either side and gears can be defined by rules rather than code.
+package schematic
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"advent-of-code/aoc"
+)
+
+// A number occupies columns [Start, End) of a single row.
+type Number struct {
+	ID    int
+	Value int
+	Row   int
+	Start int
+	End   int
+}
+
+type Symbol struct {
+	ID     int
+	Char   rune
+	Row    int
+	Column int
+}
+
+type Schematic struct {
+	Lines   []string
+	Numbers []Number
+	Symbols []Symbol
+
+	// Number ID for every cell covered by a digit.
+	number_at map[[2]int]int
+}
+
+func is_symbol(char rune) bool {
+	return char != '.' && !unicode.IsDigit(char) && !unicode.IsSpace(char)
+}
+
+// Indexes the numbers and symbols on each line. Lines need not all be the
+// same width.
+func Parse(lines []string) (*Schematic, error) {
+	schematic := &Schematic{
+		Lines:     lines,
+		number_at: make(map[[2]int]int),
+	}
+
+	for row, line := range lines {
+		runes := []rune(line)
+
+		for column := 0; column < len(runes); column++ {
+			char := runes[column]
+
+			if is_symbol(char) {
+				schematic.Symbols = append(schematic.Symbols, Symbol{len(schematic.Symbols), char, row, column})
+				continue
+			}
+
+			if !unicode.IsDigit(char) {
+				continue
+			}
+
+			end := column
+			for end < len(runes) && unicode.IsDigit(runes[end]) {
+				end++
+			}
+
+			// Use an ID not the value because the same value may appear twice!
+			value, err := strconv.Atoi(string(runes[column:end]))
+			if err != nil {
+				return nil, &aoc.InputError{
+					Line:     row + 1,
+					Column:   len(string(runes[:column])) + 1,
+					Expected: "a number that fits in an int",
+					Found:    aoc.Found(string(runes[column:end])),
+					Text:     line,
+				}
+			}
+
+			number := Number{len(schematic.Numbers), value, row, column, end}
+			schematic.Numbers = append(schematic.Numbers, number)
+
+			for x := column; x < end; x++ {
+				schematic.number_at[[2]int{row, x}] = number.ID
+			}
+
+			column = end - 1
+		}
+	}
+
+	return schematic, nil
+}
+
+// The numbers touching the symbol, including diagonally, in ID order.
+func (schematic *Schematic) Adjacent(symbol Symbol) []Number {
+	seen := make(map[int]bool)
+	numbers := make([]Number, 0)
+
+	for row := symbol.Row - 1; row <= symbol.Row+1; row++ {
+		for column := symbol.Column - 1; column <= symbol.Column+1; column++ {
+			id, ok := schematic.number_at[[2]int{row, column}]
+
+			if ok && !seen[id] {
+				seen[id] = true
+				numbers = append(numbers, schematic.Numbers[id])
+			}
+		}
+	}
+
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i].ID < numbers[j].ID })
+
+	return numbers
+}
+
+// The symbols inside the number's bounding box grown by one cell.
+func (schematic *Schematic) SymbolsAround(number Number) []Symbol {
+	symbols := make([]Symbol, 0)
+
+	for _, symbol := range schematic.Symbols {
+		if symbol.Row >= number.Row-1 && symbol.Row <= number.Row+1 &&
+			symbol.Column >= number.Start-1 && symbol.Column <= number.End {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	return symbols
+}
+
+// A symbol class is a set of symbol characters; the empty class matches
+// every symbol.
+func in_class(char rune, class string) bool {
+	return class == "" || strings.ContainsRune(class, char)
+}
+
+// Numbers adjacent to at least one symbol in the class, each listed once.
+func (schematic *Schematic) PartNumbers(class string) []Number {
+	is_part := make([]bool, len(schematic.Numbers))
+
+	for _, symbol := range schematic.Symbols {
+		if !in_class(symbol.Char, class) {
+			continue
+		}
+
+		for _, number := range schematic.Adjacent(symbol) {
+			is_part[number.ID] = true
+		}
+	}
+
+	part_numbers := make([]Number, 0)
+
+	for id, ok := range is_part {
+		if ok {
+			part_numbers = append(part_numbers, schematic.Numbers[id])
+		}
+	}
+
+	return part_numbers
+}
+
+// Numbers not adjacent to any symbol at all.
+func (schematic *Schematic) Orphans() []Number {
+	is_part := make(map[int]bool)
+
+	for _, number := range schematic.PartNumbers("") {
+		is_part[number.ID] = true
+	}
+
+	orphans := make([]Number, 0)
+
+	for _, number := range schematic.Numbers {
+		if !is_part[number.ID] {
+			orphans = append(orphans, number)
+		}
+	}
+
+	return orphans
+}
+
+// A gear is a symbol from Class with a number of adjacent parts satisfying
+// Op and Count; its ratio combines the part values with Reducer.
+type GearRule struct {
+	Text    string
+	Class   string
+	Op      string
+	Count   int
+	Reducer string
+}
+
+type Gear struct {
+	Symbol Symbol
+	Parts  []Number
+	Ratio  int
+}
+
+var gear_rule_regex = regexp.MustCompile(`^(.+?)(>=|<=|=|<|>)(\d+)(?::(product|sum))?$`)
+
+// Parses rules like "*=2" (the puzzle's gears), "#>=3:sum" or "*$<4:product".
+// The reducer defaults to product.
+func ParseGearRule(str string) (GearRule, error) {
+	matches := gear_rule_regex.FindStringSubmatch(str)
+
+	if matches == nil {
+		return GearRule{}, &aoc.InputError{File: "gear rule", Expected: "a rule like \"*=2\" or \"#>=3:sum\"", Found: aoc.Found(str), Text: str}
+	}
+
+	count, err := strconv.Atoi(matches[3])
+	if err != nil {
+		column := strings.LastIndex(str, matches[3]) + 1
+		return GearRule{}, &aoc.InputError{File: "gear rule", Column: column, Expected: "a count that fits in an int", Found: aoc.Found(matches[3]), Text: str}
+	}
+
+	reducer := matches[4]
+	if reducer == "" {
+		reducer = "product"
+	}
+
+	return GearRule{str, matches[1], matches[2], count, reducer}, nil
+}
+
+func (rule GearRule) matches(n_parts int) bool {
+	switch rule.Op {
+	case "=":
+		return n_parts == rule.Count
+	case ">=":
+		return n_parts >= rule.Count
+	case "<=":
+		return n_parts <= rule.Count
+	case ">":
+		return n_parts > rule.Count
+	case "<":
+		return n_parts < rule.Count
+	}
+
+	panic("unknown gear rule operator " + rule.Op)
+}
+
+func (rule GearRule) reduce(parts []Number) int {
+	if rule.Reducer == "sum" {
+		total := 0
+		for _, part := range parts {
+			total += part.Value
+		}
+		return total
+	}
+
+	total := 1
+	for _, part := range parts {
+		total *= part.Value
+	}
+	return total
+}
+
+// Every symbol that is a gear under the rule. Symbols with no adjacent
+// parts are never gears, whatever the rule says.
+func (schematic *Schematic) Gears(rule GearRule) []Gear {
+	gears := make([]Gear, 0)
+
+	for _, symbol := range schematic.Symbols {
+		if !in_class(symbol.Char, rule.Class) {
+			continue
+		}
+
+		parts := schematic.Adjacent(symbol)
+
+		if len(parts) == 0 || !rule.matches(len(parts)) {
+			continue
+		}
+
+		gears = append(gears, Gear{symbol, parts, rule.reduce(parts)})
+	}
+
+	return gears
+}
+
+// Something suspicious about the schematic. Row and Column are 0-indexed;
+// Column is -1 for problems with a whole row.
+type Problem struct {
+	Row     int
+	Column  int
+	Message string
+}
+
+func (problem Problem) String() string {
+	if problem.Column < 0 {
+		return fmt.Sprintf("line %d: %s", problem.Row+1, problem.Message)
+	}
+
+	return fmt.Sprintf("line %d, column %d: %s", problem.Row+1, problem.Column+1, problem.Message)
+}
+
+func (schematic *Schematic) width() int {
+	width := 0
+
+	for _, line := range schematic.Lines {
+		width = max(width, len([]rune(line)))
+	}
+
+	return width
+}
+
+func describe_symbols(symbols []Symbol) string {
+	descriptions := make([]string, len(symbols))
+
+	for i, symbol := range symbols {
+		descriptions[i] = fmt.Sprintf("%c at %d,%d", symbol.Char, symbol.Row+1, symbol.Column+1)
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// Checks that the schematic is rectangular and reports numbers that are
+// counted more than once in spirit: those touching several symbols, those
+// running into the edge of a short row, and those that belong to more than
+// one gear under any of the rules.
+func (schematic *Schematic) Lint(rules ...GearRule) []Problem {
+	problems := make([]Problem, 0)
+
+	width := schematic.width()
+
+	for row, line := range schematic.Lines {
+		if n := len([]rune(line)); n != width {
+			problems = append(problems, Problem{row, -1, fmt.Sprintf("row is %d wide, the widest row is %d", n, width)})
+		}
+	}
+
+	for _, number := range schematic.Numbers {
+		if symbols := schematic.SymbolsAround(number); len(symbols) > 1 {
+			problems = append(problems, Problem{number.Row, number.Start,
+				fmt.Sprintf("%d touches %d symbols: %s", number.Value, len(symbols), describe_symbols(symbols))})
+		}
+
+		if number.End == len([]rune(schematic.Lines[number.Row])) && number.End < width {
+			problems = append(problems, Problem{number.Row, number.Start,
+				fmt.Sprintf("%d runs into the end of a short row", number.Value)})
+		}
+	}
+
+	for _, rule := range rules {
+		gears_of := make(map[int][]Symbol)
+
+		for _, gear := range schematic.Gears(rule) {
+			for _, part := range gear.Parts {
+				gears_of[part.ID] = append(gears_of[part.ID], gear.Symbol)
+			}
+		}
+
+		for _, number := range schematic.Numbers {
+			if gears := gears_of[number.ID]; len(gears) > 1 {
+				problems = append(problems, Problem{number.Row, number.Start,
+					fmt.Sprintf("%d is shared by %d gears under %s: %s", number.Value, len(gears), rule.Text, describe_symbols(gears))})
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Row != problems[j].Row {
+			return problems[i].Row < problems[j].Row
+		}
+		return problems[i].Column < problems[j].Column
+	})
+
+	return problems
+}
+
+const (
+	ANSI_RESET  = "\033[0m"
+	ANSI_RED    = "\033[31m"
+	ANSI_GREEN  = "\033[32m"
+	ANSI_YELLOW = "\033[1;33m"
+	ANSI_DIM    = "\033[2m"
+)
+
+// Draws the schematic with the counted numbers in green, the other numbers
+// in red and the symbols in yellow. Short rows are padded with dim '~' so
+// raggedness is visible.
+func (schematic *Schematic) Render(w io.Writer, counted []Number) {
+	is_counted := make(map[int]bool)
+	for _, number := range counted {
+		is_counted[number.ID] = true
+	}
+
+	width := schematic.width()
+
+	for row, line := range schematic.Lines {
+		runes := []rune(line)
+
+		for column, char := range runes {
+			color := ""
+
+			if id, ok := schematic.number_at[[2]int{row, column}]; ok {
+				color = ANSI_RED
+				if is_counted[id] {
+					color = ANSI_GREEN
+				}
+			} else if is_symbol(char) {
+				color = ANSI_YELLOW
+			}
+
+			if color == "" {
+				fmt.Fprint(w, string(char))
+			} else {
+				fmt.Fprint(w, color, string(char), ANSI_RESET)
+			}
+		}
+
+		if len(runes) < width {
+			fmt.Fprint(w, ANSI_DIM, strings.Repeat("~", width-len(runes)), ANSI_RESET)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, ANSI_GREEN+"counted"+ANSI_RESET, ANSI_RED+"not counted"+ANSI_RESET, ANSI_YELLOW+"symbol"+ANSI_RESET)
+}
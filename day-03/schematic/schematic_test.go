@@ -0,0 +1,231 @@
+package schematic
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+var example = []string{
+	"467..114..",
+	"...*......",
+	"..35..633.",
+	"......#...",
+	"617*......",
+	".....+.58.",
+	"..592.....",
+	"......755.",
+	"...$.*....",
+	".664.598..",
+}
+
+func parse(t *testing.T, lines []string) *Schematic {
+	t.Helper()
+
+	schematic, err := Parse(lines)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	return schematic
+}
+
+func values(numbers []Number) []int {
+	result := make([]int, len(numbers))
+	for i, number := range numbers {
+		result[i] = number.Value
+	}
+	return result
+}
+
+func total(numbers []Number) int {
+	sum := 0
+	for _, number := range numbers {
+		sum += number.Value
+	}
+	return sum
+}
+
+func TestParse(t *testing.T) {
+	schematic := parse(t, example)
+
+	if len(schematic.Numbers) != 10 || len(schematic.Symbols) != 6 {
+		t.Fatalf("Parse found %d numbers and %d symbols, want 10 and 6", len(schematic.Numbers), len(schematic.Symbols))
+	}
+
+	if first := schematic.Numbers[0]; first.Value != 467 || first.Row != 0 || first.Start != 0 || first.End != 3 {
+		t.Errorf("first number is %+v, want 467 at row 0, columns 0 to 3", first)
+	}
+
+	if _, err := Parse([]string{"..99999999999999999999.."}); err == nil {
+		t.Errorf("Parse accepted a number too big for an int")
+	}
+}
+
+func TestPartNumbers(t *testing.T) {
+	tests := []struct {
+		class string
+		want  int
+	}{
+		{"", 4361},
+		{"*", 467 + 35 + 617 + 755 + 598},
+		{"#", 633},
+		{"%", 0},
+	}
+
+	schematic := parse(t, example)
+
+	for _, test := range tests {
+		if got := total(schematic.PartNumbers(test.class)); got != test.want {
+			t.Errorf("PartNumbers(%q) add up to %d, want %d", test.class, got, test.want)
+		}
+	}
+
+	if got := values(schematic.Orphans()); !slices.Equal(got, []int{114, 58}) {
+		t.Errorf("Orphans() = %v, want [114 58]", got)
+	}
+}
+
+func TestAdjacent(t *testing.T) {
+	schematic := parse(t, example)
+
+	// The same value twice next to one symbol counts twice.
+	repeated := parse(t, []string{
+		"12.12",
+		"..*..",
+	})
+
+	tests := []struct {
+		schematic *Schematic
+		symbol    int
+		want      []int
+	}{
+		{schematic, 0, []int{467, 35}},
+		{schematic, 1, []int{633}},
+		{schematic, 5, []int{755, 598}},
+		{repeated, 0, []int{12, 12}},
+	}
+
+	for _, test := range tests {
+		symbol := test.schematic.Symbols[test.symbol]
+		if got := values(test.schematic.Adjacent(symbol)); !slices.Equal(got, test.want) {
+			t.Errorf("Adjacent(%c at %d,%d) = %v, want %v", symbol.Char, symbol.Row, symbol.Column, got, test.want)
+		}
+	}
+}
+
+func TestParseGearRule(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  GearRule
+		wrong bool
+	}{
+		{"*=2", GearRule{"*=2", "*", "=", 2, "product"}, false},
+		{"#>=3:sum", GearRule{"#>=3:sum", "#", ">=", 3, "sum"}, false},
+		{"*$<4:product", GearRule{"*$<4:product", "*$", "<", 4, "product"}, false},
+		{"*", GearRule{}, true},
+		{"*=2:mean", GearRule{}, true},
+		{"*=99999999999999999999", GearRule{}, true},
+	}
+
+	for _, test := range tests {
+		rule, err := ParseGearRule(test.text)
+
+		if test.wrong {
+			if err == nil {
+				t.Errorf("ParseGearRule(%q) = %+v, want an error", test.text, rule)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseGearRule(%q) failed: %v", test.text, err)
+		} else if rule != test.want {
+			t.Errorf("ParseGearRule(%q) = %+v, want %+v", test.text, rule, test.want)
+		}
+	}
+}
+
+func TestGears(t *testing.T) {
+	tests := []struct {
+		rule  string
+		count int
+		total int
+	}{
+		{"*=2", 2, 467*35 + 755*598},
+		{"*>=1", 3, 467*35 + 617 + 755*598},
+		{"*=1:sum", 1, 617},
+		{"*<2", 1, 617},
+		{"#$+>0:sum", 3, 633 + 664 + 592},
+	}
+
+	schematic := parse(t, example)
+
+	for _, test := range tests {
+		rule, err := ParseGearRule(test.rule)
+		if err != nil {
+			t.Fatalf("ParseGearRule(%q) failed: %v", test.rule, err)
+		}
+
+		gears := schematic.Gears(rule)
+
+		sum := 0
+		for _, gear := range gears {
+			sum += gear.Ratio
+		}
+
+		if len(gears) != test.count || sum != test.total {
+			t.Errorf("Gears(%q): %d gears with ratios adding up to %d, want %d and %d", test.rule, len(gears), sum, test.count, test.total)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	schematic := parse(t, []string{
+		"1*2*3",
+		"45",
+	})
+
+	rule, _ := ParseGearRule("*>=1")
+
+	problems := schematic.Lint(rule)
+
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.String()
+	}
+
+	want := []string{
+		"line 1, column 3: 2 touches 2 symbols: * at 1,2, * at 1,4",
+		"line 1, column 3: 2 is shared by 2 gears under *>=1: * at 1,2, * at 1,4",
+		"line 2: row is 2 wide, the widest row is 5",
+		"line 2, column 1: 45 runs into the end of a short row",
+	}
+
+	if len(messages) != len(want) {
+		t.Fatalf("Lint() = %q, want %d problems", messages, len(want))
+	}
+
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("problem %d is %q, want %q", i, messages[i], want[i])
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	schematic := parse(t, []string{"1*.", "2"})
+
+	output := strings.Builder{}
+	schematic.Render(&output, schematic.PartNumbers(""))
+
+	lines := strings.Split(output.String(), "\n")
+
+	if want := ANSI_GREEN + "1" + ANSI_RESET + ANSI_YELLOW + "*" + ANSI_RESET + "."; lines[0] != want {
+		t.Errorf("first row is %q, want %q", lines[0], want)
+	}
+
+	if want := ANSI_GREEN + "2" + ANSI_RESET + ANSI_DIM + "~~" + ANSI_RESET; lines[1] != want {
+		t.Errorf("second row is %q, want %q", lines[1], want)
+	}
+}
@@ -1,105 +1,49 @@
 package main
 
-import "fmt"
-import "os"
-import "log"
-import "regexp"
-import "bufio"
-import "strconv"
-import "slices"
-import "strings"
+import "advent-of-code/aoc"
+import "advent-of-code/day-04/scratchcard"
 
-var numbers_regex = regexp.MustCompile(`\d+`)
+var parsing = aoc.Trace(aoc.Parse)
 
-type CardResult struct {
-	winning_numbers []int
-	card_numbers    []int
-	id              int
-	matches         int
-	score           int
-}
-
-func parse_number_string(str string) []int {
-	parsed_numbers := numbers_regex.FindAllString(strings.TrimSpace(str), -1)
-	integers := make([]int, len(parsed_numbers))
-
-	for i, v := range parsed_numbers {
-		integers[i], _ = strconv.Atoi(v)
+// A card is worth a point for its first match, doubled for each one after.
+func score(matches int) int {
+	if matches == 0 {
+		return 0
 	}
 
-	return integers
+	// Go does not have exponentiation for integers...
+	return 1 << (matches - 1)
 }
 
-func parse_card_string(str string) int {
-	parsed_number := numbers_regex.FindString(strings.TrimSpace(str))
-	integer, _ := strconv.Atoi(parsed_number)
-
-	return integer
+func main() {
+	aoc.Main(4, 1, solve)
 }
 
-func calculate_score(result CardResult) (int, int) {
-	number_of_matches := 0
+func solve(run *aoc.Run) {
+	input := run.Input
 
-	for _, v := range result.card_numbers {
-		if slices.Contains(result.winning_numbers, v) {
-			number_of_matches++
-		}
-	}
-
-	score := 0
+	cards := []scratchcard.Card{}
 
-	if number_of_matches > 0 {
-		// Go does not have exponentiation for integers...
-		score = 1 << (number_of_matches - 1)
-	}
-
-	return number_of_matches, score
-}
+	for input.Scan() {
+		text := input.Text()
 
-func parse_card(str string) CardResult {
-	split_first := strings.Split(str, ":")
-	card_identifier := split_first[0]
-	winning_and_card := split_first[1]
+		card, err := scratchcard.ParseCard(text)
+		aoc.Check(input.Wrap(err))
 
-	split_second := strings.Split(winning_and_card, "|")
-	winning_numbers := split_second[0]
-	card_numbers := split_second[1]
+		parsing.Debug("Output: ", card)
+		parsing.Debug(" Base: ", text)
 
-	result := CardResult{
-		winning_numbers: parse_number_string(winning_numbers),
-		card_numbers:    parse_number_string(card_numbers),
-		id:              parse_card_string(card_identifier),
-		score:           0,
+		cards = append(cards, card)
 	}
 
-	result.matches, result.score = calculate_score(result)
-
-	return result
-}
-
-func main() {
-	DEBUG := true
-
-	scanner := bufio.NewScanner(os.Stdin)
+	aoc.Check(input.Err())
+	run.Parsed()
 
 	total := 0
 
-	for scanner.Scan() {
-		text := scanner.Text()
-
-		card_result := parse_card(text)
-
-		if DEBUG {
-			fmt.Println("Output: ", card_result)
-			fmt.Println(" Base: ", text)
-		}
-
-		total += card_result.score
-	}
-
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
+	for _, card := range cards {
+		total += score(card.Matches())
 	}
 
-	fmt.Println("Total: ", total)
+	run.Answer("Total", total)
 }
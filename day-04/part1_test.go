@@ -0,0 +1,11 @@
+package main
+
+import (
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func BenchmarkPart1(b *testing.B) {
+	aoc.Benchmark(b, 4, 1, "real.txt", solve)
+}
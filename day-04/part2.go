@@ -1,127 +1,143 @@
 package main
 
 import "fmt"
-import "os"
 import "log"
-import "regexp"
-import "bufio"
-import "strconv"
-import "slices"
-import "strings"
-
-var numbers_regex = regexp.MustCompile(`\d+`)
-
-type CardResult struct {
-	winning_numbers []int
-	card_numbers    []int
-	id              int
-	matches         int
-}
-
-func parse_number_string(str string) []int {
-	parsed_numbers := numbers_regex.FindAllString(strings.TrimSpace(str), -1)
-	integers := make([]int, len(parsed_numbers))
-
-	for i, v := range parsed_numbers {
-		integers[i], _ = strconv.Atoi(v)
-	}
-
-	return integers
-}
-
-func parse_card_string(str string) int {
-	parsed_number := numbers_regex.FindString(strings.TrimSpace(str))
-	integer, _ := strconv.Atoi(parsed_number)
-
-	return integer
+import "os"
+import "flag"
+import "math/big"
+
+import "advent-of-code/aoc"
+import "advent-of-code/day-04/scratchcard"
+
+var parsing = aoc.Trace(aoc.Parse)
+
+// A copy rule decides which cards a card with some matches wins copies of,
+// and how many copies of each. Cards are processed once each, in order (or
+// from the last card back if reverse is set), so copies won by a card that
+// has already been processed are counted but win nothing further.
+type CopyRule struct {
+	description string
+	reverse     bool
+	targets     func(card int, matches int, n_cards int) (cards []int, copies_each int)
 }
 
-func calculate_matches(result CardResult) int {
-	number_of_matches := 0
-
-	for _, v := range result.card_numbers {
-		if slices.Contains(result.winning_numbers, v) {
-			number_of_matches++
-		}
-	}
-
-	return number_of_matches
+var copy_rules = map[string]CopyRule{
+	"next": {
+		description: "N matches win one copy each of the next N cards (the puzzle)",
+		targets: func(card int, matches int, n_cards int) ([]int, int) {
+			cards := []int{}
+			for x := card + 1; x <= card+matches && x < n_cards; x++ {
+				cards = append(cards, x)
+			}
+			return cards, 1
+		},
+	},
+	"wrap": {
+		description: "as next, but wrapping around to the first card after the last",
+		targets: func(card int, matches int, n_cards int) ([]int, int) {
+			cards := []int{}
+			for x := 1; x <= matches; x++ {
+				cards = append(cards, (card+x)%n_cards)
+			}
+			return cards, 1
+		},
+	},
+	"previous": {
+		description: "N matches win one copy each of the previous N cards",
+		reverse:     true,
+		targets: func(card int, matches int, n_cards int) ([]int, int) {
+			cards := []int{}
+			for x := card - 1; x >= card-matches && x >= 0; x-- {
+				cards = append(cards, x)
+			}
+			return cards, 1
+		},
+	},
+	"scaled": {
+		description: "N matches win N copies each of the next N cards",
+		targets: func(card int, matches int, n_cards int) ([]int, int) {
+			cards := []int{}
+			for x := card + 1; x <= card+matches && x < n_cards; x++ {
+				cards = append(cards, x)
+			}
+			return cards, matches
+		},
+	},
 }
 
-func calculate_score(result []CardResult) ([]int, int) {
-	number_of_cards := make([]int, len(result))
+// Copy counts grow exponentially on generated inputs, so they are kept as
+// big integers.
+func calculate_score(result []scratchcard.Card, rule CopyRule) ([]*big.Int, *big.Int) {
+	number_of_cards := make([]*big.Int, len(result))
 
 	for i := range number_of_cards {
-		number_of_cards[i] = 1
+		number_of_cards[i] = big.NewInt(1)
 	}
 
-	for i, v := range result {
-		me_copies := number_of_cards[i]
+	for n := range result {
+		i := n
+		if rule.reverse {
+			i = len(result) - 1 - n
+		}
+
+		matches := result[i].Matches()
 
-		if v.matches == 0 {
+		if matches == 0 {
 			continue
 		}
 
-		for x := 1; x <= v.matches; x++ {
-			number_of_cards[i+x] += me_copies
+		cards, copies_each := rule.targets(i, matches, len(result))
+		won := new(big.Int).Mul(number_of_cards[i], big.NewInt(int64(copies_each)))
+
+		for _, x := range cards {
+			number_of_cards[x].Add(number_of_cards[x], won)
 		}
 	}
 
-	total_score := 0
+	total_score := new(big.Int)
 
 	for _, v := range number_of_cards {
-		total_score += v
+		total_score.Add(total_score, v)
 	}
 
 	return number_of_cards, total_score
 }
 
-func parse_card(str string) CardResult {
-	split_first := strings.Split(str, ":")
-	card_identifier := split_first[0]
-	winning_and_card := split_first[1]
-
-	split_second := strings.Split(winning_and_card, "|")
-	winning_numbers := split_second[0]
-	card_numbers := split_second[1]
-
-	result := CardResult{
-		winning_numbers: parse_number_string(winning_numbers),
-		card_numbers:    parse_number_string(card_numbers),
-		id:              parse_card_string(card_identifier),
-	}
-
-	result.matches = calculate_matches(result)
-
-	return result
-}
+var rule_name = flag.String("rule", "next", "copy rule: next, wrap, previous or scaled")
 
 func main() {
-	DEBUG := true
+	aoc.Main(4, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	rule, ok := copy_rules[*rule_name]
+	if !ok {
+		for name, rule := range copy_rules {
+			fmt.Fprintln(os.Stderr, name+":", rule.description)
+		}
+		log.Fatal("Unknown copy rule: ", *rule_name)
+	}
 
-	cards := make([]CardResult, 0)
+	input := run.Input
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	cards := make([]scratchcard.Card, 0)
 
-		card_result := parse_card(text)
+	for input.Scan() {
+		text := input.Text()
 
-		if DEBUG {
-			fmt.Println("Output: ", card_result)
-			fmt.Println(" Base: ", text)
-		}
+		card, err := scratchcard.ParseCard(text)
+		aoc.Check(input.Wrap(err))
 
-		cards = append(cards, card_result)
-	}
+		parsing.Debug("Output: ", card)
+		parsing.Debug(" Base: ", text)
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
+		cards = append(cards, card)
 	}
 
-	_, total_score := calculate_score(cards)
+	aoc.Check(input.Err())
+	run.Parsed()
 
-	fmt.Println("Total: ", total_score)
+	_, total_score := calculate_score(cards, rule)
 
+	run.Answer("Total", total_score)
 }
@@ -0,0 +1,103 @@
+// Package scratchcard holds what both parts of day 4 share: reading the
+// cards, and a set of winning numbers that is quick to check card numbers
+// against.
+package scratchcard
+
+import (
+	"regexp"
+	"strconv"
+
+	"advent-of-code/aoc"
+)
+
+var card_regex = regexp.MustCompile(`^\s*Card\s+(\d+)\s*:([^|]*)\|(.*)$`)
+
+// Numbers below this go in the bitmap. Larger ones go in a map instead, so
+// a single huge number cannot make the bitmap huge.
+const bitmap_limit = 1 << 16
+
+// A bitmap over the (usually small, non-negative) card numbers, so that
+// checking a number against the winning numbers is O(1).
+type NumberSet struct {
+	bits  []uint64
+	large map[int]bool
+}
+
+func NewNumberSet(numbers []int) NumberSet {
+	set := NumberSet{}
+
+	for _, number := range numbers {
+		if number < 0 || number >= bitmap_limit {
+			if set.large == nil {
+				set.large = make(map[int]bool)
+			}
+			set.large[number] = true
+			continue
+		}
+
+		for number/64 >= len(set.bits) {
+			set.bits = append(set.bits, 0)
+		}
+		set.bits[number/64] |= 1 << (number % 64)
+	}
+
+	return set
+}
+
+func (set NumberSet) Contains(number int) bool {
+	if number < 0 || number >= bitmap_limit {
+		return set.large[number]
+	}
+
+	return number/64 < len(set.bits) && set.bits[number/64]&(1<<(number%64)) != 0
+}
+
+type Card struct {
+	ID      int
+	Winning []int
+	Numbers []int
+}
+
+// Counts how many of the card's numbers are winning numbers.
+func (card Card) Matches() int {
+	number_of_matches := 0
+	winning_numbers := NewNumberSet(card.Winning)
+
+	for _, v := range card.Numbers {
+		if winning_numbers.Contains(v) {
+			number_of_matches++
+		}
+	}
+
+	return number_of_matches
+}
+
+// Parses a line of the form "Card <id>: <winning numbers> | <numbers>".
+func ParseCard(str string) (Card, error) {
+	matches := card_regex.FindStringSubmatchIndex(str)
+
+	if matches == nil {
+		return Card{}, aoc.Expected(1, "\"Card <id>: <winning numbers> | <numbers>\"", aoc.Found(str))
+	}
+
+	id, err := strconv.Atoi(str[matches[2]:matches[3]])
+	if err != nil {
+		return Card{}, aoc.Expected(matches[2]+1, "a card id that fits in an int", aoc.Found(str[matches[2]:matches[3]]))
+	}
+
+	winning_numbers, err := aoc.ParseNumbers(str[matches[4]:matches[5]], matches[4])
+	if err != nil {
+		return Card{}, err
+	}
+
+	card_numbers, err := aoc.ParseNumbers(str[matches[6]:matches[7]], matches[6])
+	if err != nil {
+		return Card{}, err
+	}
+
+	return Card{
+		ID:      id,
+		Winning: winning_numbers,
+		Numbers: card_numbers,
+	}, nil
+}
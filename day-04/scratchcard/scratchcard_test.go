@@ -0,0 +1,81 @@
+package scratchcard
+
+import (
+	"errors"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func TestNumberSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []int
+		in      []int
+		out     []int
+		// How many words the bitmap may use.
+		words int
+	}{
+		{"empty", nil, nil, []int{0, 1, 63, 64}, 0},
+		{"small", []int{41, 48, 83, 86, 17}, []int{17, 41, 48, 83, 86}, []int{0, 16, 18, 84, 1000}, 2},
+		{"word edges", []int{0, 63, 64, 127}, []int{0, 63, 64, 127}, []int{1, 62, 65, 128}, 2},
+		// Too big for the bitmap, so they go in the map.
+		{"huge", []int{5, 1 << 40, bitmap_limit}, []int{5, 1 << 40, bitmap_limit}, []int{6, 1<<40 + 1, bitmap_limit - 1}, 1},
+		{"negative", []int{-3}, []int{-3}, []int{3, -4}, 0},
+	}
+
+	for _, test := range tests {
+		set := NewNumberSet(test.numbers)
+
+		for _, number := range test.in {
+			if !set.Contains(number) {
+				t.Errorf("%s: set of %v does not contain %d", test.name, test.numbers, number)
+			}
+		}
+
+		for _, number := range test.out {
+			if set.Contains(number) {
+				t.Errorf("%s: set of %v contains %d", test.name, test.numbers, number)
+			}
+		}
+
+		if len(set.bits) > test.words {
+			t.Errorf("%s: bitmap uses %d words, want at most %d", test.name, len(set.bits), test.words)
+		}
+	}
+}
+
+func TestParseCard(t *testing.T) {
+	tests := []struct {
+		text    string
+		id      int
+		matches int
+		column  int
+	}{
+		{"Card 1: 41 48 83 86 17 | 83 86  6 31 17  9 48 53", 1, 4, 0},
+		{"Card   6: 31 18 13 56 72 | 74 77 10 23 35 67 36 11", 6, 0, 0},
+		{"Card 2: 1 1 | 1 1", 2, 2, 0},
+		{"Card 3: | 1 2", 3, 0, 0},
+		{"Card 4 41 48 | 83 86", 0, 0, 1},
+		{"Card 99999999999999999999: 41 | 83", 0, 0, 6},
+		{"Card 5: 41 x8 | 83 86", 0, 0, 12},
+		{"Card 5: 41 48 | 83 -86", 0, 0, 20},
+	}
+
+	for _, test := range tests {
+		card, err := ParseCard(test.text)
+
+		if test.column == 0 {
+			if err != nil || card.ID != test.id || card.Matches() != test.matches {
+				t.Errorf("ParseCard(%q) = card %d with %d matches, %v, want card %d with %d matches",
+					test.text, card.ID, card.Matches(), err, test.id, test.matches)
+			}
+			continue
+		}
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) || input_err.Column != test.column {
+			t.Errorf("ParseCard(%q) = %v, want an error at column %d", test.text, err, test.column)
+		}
+	}
+}
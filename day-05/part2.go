@@ -3,19 +3,19 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"log"
-	"os"
 	"regexp"
-	"strconv"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
-var numbers_regex = regexp.MustCompile(`\d+`)
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
 
 // Capture groups give mapping ingredients (0 to 1)
-var mapping_regex = regexp.MustCompile(`([a-z]+)-to-([a-z]+) map`)
+var mapping_regex = regexp.MustCompile(`^\s*([a-z]+)-to-([a-z]+) map:\s*$`)
 
 // var MAXIMUM_NUMBER_OF_SEEDS = 100
 
@@ -43,40 +43,65 @@ type SeedMapping struct {
 	ranges []MappingRange
 }
 
-func parse_name_string(str string) (string, string) {
+func parse_name_string(str string) (string, string, error) {
 	matches := mapping_regex.FindStringSubmatch(str)
 
-	return matches[1], matches[2]
+	if matches == nil {
+		return "", "", aoc.Expected(1, "\"<from>-to-<to> map:\"", aoc.Found(str))
+	}
+
+	return matches[1], matches[2], nil
 }
 
-func parse_mapping(strs []string) SeedMapping {
+// Parses one block of the almanac, the first line of which is line
+// first_line of the input.
+func parse_mapping(strs []string, first_line int) (SeedMapping, error) {
 	result := SeedMapping{
 		ranges: make([]MappingRange, 0),
 	}
 
-	for _, v := range strs {
+	for i, v := range strs {
+		var err error
+
 		if strings.Contains(v, ":") {
-			result.from, result.to = parse_name_string(v)
-		} else {
-			match_numbers := numbers_regex.FindAllString(strings.TrimSpace(v), -1)
-
-			to_start, _ := strconv.Atoi(match_numbers[0])
-			from_start, _ := strconv.Atoi(match_numbers[1])
-			length, _ := strconv.Atoi(match_numbers[2])
-
-			new_mapping_range := MappingRange{
-				start_from: from_start,
-				stop_from:  from_start + length - 1,
-				start_to:   to_start,
-				stop_to:    to_start + length - 1,
-				width:      length,
+			result.from, result.to, err = parse_name_string(v)
+			if err != nil {
+				return result, aoc.AtLine(err, first_line+i, v)
 			}
 
-			result.ranges = append(result.ranges, new_mapping_range)
+			continue
+		}
+
+		match_numbers, err := aoc.ParseNumbers(v, 0)
+		if err != nil {
+			return result, aoc.AtLine(err, first_line+i, v)
+		}
+
+		if len(match_numbers) != 3 {
+			err := aoc.Expected(0, "\"<destination start> <source start> <length>\"", fmt.Sprintf("%d numbers", len(match_numbers)))
+			return result, aoc.AtLine(err, first_line+i, v)
+		}
+
+		to_start := match_numbers[0]
+		from_start := match_numbers[1]
+		length := match_numbers[2]
+
+		new_mapping_range := MappingRange{
+			start_from: from_start,
+			stop_from:  from_start + length - 1,
+			start_to:   to_start,
+			stop_to:    to_start + length - 1,
+			width:      length,
 		}
+
+		result.ranges = append(result.ranges, new_mapping_range)
+	}
+
+	if result.from == "" {
+		return result, aoc.AtLine(aoc.Expected(1, "\"<from>-to-<to> map:\"", aoc.Found(strs[0])), first_line, strs[0])
 	}
 
-	return result
+	return result, nil
 }
 
 func make_hops(have string, want string, mappings []SeedMapping, number int) int {
@@ -96,22 +121,30 @@ func make_hops(have string, want string, mappings []SeedMapping, number int) int
 		}
 	}
 
-	fmt.Println("FAILED TO FIND MAPPING FOR: ", have, want, number)
-	os.Exit(1)
+	log.Fatal("Failed to find mapping for: ", have, " ", want, " ", number)
 
 	return 1
 }
 
-func parse_seeds(str string) [][]int {
+func parse_seeds(str string) ([][]int, error) {
 	result := make([][]int, 0)
 
-	// Consider seeds in batches.
+	_, list, _ := strings.Cut(str, ":")
 
-	string_seeds := numbers_regex.FindAllString(strings.TrimSpace(str), -1)
+	numbers, err := aoc.ParseNumbers(list, len(str)-len(list))
+	if err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < len(string_seeds)/2; i++ {
-		from, _ := strconv.Atoi(string_seeds[i*2])
-		length, _ := strconv.Atoi(string_seeds[i*2+1])
+	if len(numbers)%2 != 0 {
+		return nil, aoc.Expected(0, "pairs of seed start and length", fmt.Sprintf("%d numbers", len(numbers)))
+	}
+
+	// Consider seeds in batches.
+
+	for i := 0; i < len(numbers)/2; i++ {
+		from := numbers[i*2]
+		length := numbers[i*2+1]
 
 		range_array := make([]int, 2)
 		range_array[0] = from
@@ -120,78 +153,110 @@ func parse_seeds(str string) [][]int {
 		result = append(result, range_array)
 	}
 
-	return result
+	return result, nil
 }
 
 func main() {
-	DEBUG := false
+	aoc.Main(5, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	mappings := make([]SeedMapping, 0)
 	seeds := make([][]int, 0)
 
 	buffer := make([]string, 0)
+	buffer_line := 0
 
-	for scanner.Scan() {
-		text := scanner.Text()
+	for input.Scan() {
+		text := input.Text()
 
 		if strings.Contains(text, "seeds") {
-			seeds = parse_seeds(text)
+			var err error
+			seeds, err = parse_seeds(text)
+			aoc.Check(input.Wrap(err))
 
-			if DEBUG {
-				fmt.Println("Seeds input: ", text)
-				fmt.Println("Seeds: ", seeds)
-			}
+			parsing.Debug("Seeds input: ", text)
+			parsing.Debug("Seeds: ", seeds)
 		} else {
 			if len(text) == 0 {
 				if len(buffer) == 0 {
 					continue
 				}
 
-				if DEBUG {
-					fmt.Println("Buffer: ")
+				if parsing.Enabled(aoc.Debug) {
+					parsing.Debug("Buffer: ")
 					for _, v := range buffer {
-						fmt.Println(v)
+						parsing.Debug(v)
 					}
 				}
 
-				this_mapping := parse_mapping(buffer)
+				this_mapping, err := parse_mapping(buffer, buffer_line)
+				aoc.Check(input.Wrap(err))
 
-				if DEBUG {
-					fmt.Println("Output: ", this_mapping)
-				}
+				parsing.Debug("Output: ", this_mapping)
 
 				mappings = append(mappings, this_mapping)
 				buffer = make([]string, 0)
 			} else {
+				if len(buffer) == 0 {
+					buffer_line = input.Line()
+				}
 				buffer = append(buffer, text)
 			}
 		}
 
 	}
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
-	}
+	aoc.Check(input.Err())
+	run.Parsed()
 
 	smallest_location := 1000000000000
 
+	// Checked once: the loop below runs billions of times.
+	trace_seeds := solving.Enabled(aoc.Debug)
+
+	ctx := run.Context()
+
+	total_seeds := 0
 	for _, v := range seeds {
-		fmt.Println("Starting seed block: ", v)
+		total_seeds += v[1] - v[0]
+	}
+
+	progress := run.Progress("Checking seeds", total_seeds)
+
+seeds:
+	for _, v := range seeds {
+		// Seeds in this block not yet counted as progress.
+		counted := v[0]
+
 		for i := v[0]; i < v[1]; i++ {
+			// Likewise only now and then.
+			if i%(1<<20) == 0 {
+				progress.Add(i - counted)
+				counted = i
+
+				if ctx.Err() != nil {
+					solving.Info("Stopped at seed ", i, ": ", ctx.Err())
+					break seeds
+				}
+			}
+
 			new_location := make_hops("seed", "location", mappings, i)
 			smallest_location = min(new_location, smallest_location)
 
-			if DEBUG {
-				fmt.Println("Seed: ", i)
-				fmt.Println("Maps to location: ", new_location)
-				fmt.Println("Smallest location so far: ", smallest_location)
+			if trace_seeds {
+				solving.Debug("Seed: ", i)
+				solving.Debug("Maps to location: ", new_location)
+				solving.Debug("Smallest location so far: ", smallest_location)
 			}
-
 		}
+
+		progress.Add(v[1] - counted)
 	}
 
-	fmt.Println("Smallest location: ", smallest_location)
+	progress.Done()
 
+	run.Answer("Smallest location", smallest_location)
 }
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func BenchmarkPart2(b *testing.B) {
+	if testing.Short() {
+		b.Skip("brute force over every seed takes minutes")
+	}
+
+	aoc.Benchmark(b, 5, 2, "real.txt", solve)
+}
@@ -0,0 +1,373 @@
+// Package camel is the rules engine behind Camel Cards (day 7). The card
+// order, the wildcard cards and the hand size all come from a Rules value,
+// so part 1 and part 2 are just two presets.
+package camel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"advent-of-code/aoc"
+)
+
+var fields_regex = regexp.MustCompile(`\S+`)
+
+// Rules describes one variant of the game. Order lists the cards from
+// weakest to strongest; any card in Wildcards stands in for whichever card
+// makes the hand strongest.
+type Rules struct {
+	Name      string `json:"name"`
+	Order     string `json:"order"`
+	Wildcards string `json:"wildcards"`
+	HandSize  int    `json:"hand_size"`
+
+	ranking    map[rune]int
+	categories []Category
+}
+
+var Part1 = Rules{
+	Name:     "part1",
+	Order:    "23456789TJQKA",
+	HandSize: 5,
+}
+
+var Part2 = Rules{
+	Name:      "part2",
+	Order:     "J23456789TQKA",
+	Wildcards: "J",
+	HandSize:  5,
+}
+
+// Names for the count signatures of a standard five card hand. Other hand
+// sizes fall back to a name built from the signature itself.
+var category_names = map[string]string{
+	"1,1,1,1,1": "high_card",
+	"2,1,1,1":   "one_pair",
+	"2,2,1":     "two_pair",
+	"3,1,1":     "three_of_a_kind",
+	"3,2":       "full_house",
+	"4,1":       "four_of_a_kind",
+	"5":         "five_of_a_kind",
+}
+
+// A hand category is identified by how many of each card it holds, sorted
+// from the largest group down. Comparing signatures lexicographically gives
+// the usual ordering (e.g. 3,2 beats 3,1,1), whatever the hand size.
+type Category struct {
+	Name      string
+	Signature []int
+	Strength  int
+}
+
+type Hand struct {
+	Text      string
+	Cards     []int
+	Bid       int
+	Signature []int
+	Category  Category
+	// The card the wildcards stood in for, or -1 if there were none.
+	Substitute int
+}
+
+// Loads rules from a JSON file, e.g.
+//
+//	{"name": "wild", "order": "J23456789TQKA", "wildcards": "J", "hand_size": 7}
+func LoadRules(path string) (Rules, error) {
+	rules := Rules{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, json_error(path, data, err)
+	}
+
+	return rules, nil
+}
+
+// Points a JSON decoding error at the line and column it happened on.
+func json_error(path string, data []byte, err error) error {
+	offset := int64(-1)
+
+	var syntax_err *json.SyntaxError
+	var type_err *json.UnmarshalTypeError
+
+	if errors.As(err, &syntax_err) {
+		offset = syntax_err.Offset
+	} else if errors.As(err, &type_err) {
+		offset = type_err.Offset
+	}
+
+	if offset < 0 {
+		return fmt.Errorf("could not parse rules %s: %w", path, err)
+	}
+
+	before := string(data[:min(offset, int64(len(data)))])
+	line_start := strings.LastIndexByte(before, '\n') + 1
+	line_end := len(before) + strings.IndexByte(string(data[len(before):])+"\n", '\n')
+
+	return &aoc.InputError{
+		File:     path,
+		Line:     strings.Count(before, "\n") + 1,
+		Column:   len(before) - line_start + 1,
+		Expected: "valid rules JSON",
+		Found:    err.Error(),
+		Text:     string(data[line_start:line_end]),
+	}
+}
+
+func signature_key(signature []int) string {
+	parts := make([]string, len(signature))
+
+	for i, count := range signature {
+		parts[i] = strconv.Itoa(count)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// All the ways of splitting n cards into groups, largest group first.
+func partitions(n int, largest int) [][]int {
+	if n == 0 {
+		return [][]int{{}}
+	}
+
+	result := [][]int{}
+
+	for first := min(n, largest); first > 0; first-- {
+		for _, rest := range partitions(n-first, first) {
+			result = append(result, append([]int{first}, rest...))
+		}
+	}
+
+	return result
+}
+
+// Checks the rules and builds the lookup tables. Must be called before the
+// rules are used to parse hands.
+func (rules *Rules) Compile() error {
+	if rules.HandSize <= 0 {
+		return fmt.Errorf("rules %q: hand size must be positive, got %d", rules.Name, rules.HandSize)
+	}
+
+	rules.ranking = make(map[rune]int)
+
+	for rank, card := range []rune(rules.Order) {
+		if _, ok := rules.ranking[card]; ok {
+			return fmt.Errorf("rules %q: card %q appears twice in the order", rules.Name, card)
+		}
+		rules.ranking[card] = rank
+	}
+
+	for _, card := range rules.Wildcards {
+		if _, ok := rules.ranking[card]; !ok {
+			return fmt.Errorf("rules %q: wildcard %q is not in the card order", rules.Name, card)
+		}
+	}
+
+	signatures := partitions(rules.HandSize, rules.HandSize)
+	sort.Slice(signatures, func(i, j int) bool {
+		return slices.Compare(signatures[i], signatures[j]) < 0
+	})
+
+	rules.categories = make([]Category, len(signatures))
+
+	for i, signature := range signatures {
+		key := signature_key(signature)
+		name, ok := category_names[key]
+		if !ok {
+			name = key
+		}
+
+		rules.categories[i] = Category{Name: name, Signature: signature, Strength: i + 1}
+	}
+
+	return nil
+}
+
+// Every category this hand size allows, weakest first.
+func (rules *Rules) Categories() []Category {
+	return rules.categories
+}
+
+func (rules *Rules) is_wildcard(card int) bool {
+	return strings.ContainsRune(rules.Wildcards, []rune(rules.Order)[card])
+}
+
+// Works out the count signature of the cards, with every wildcard added to
+// the largest group of real cards. Also returns the card that the wildcards
+// joined (the strongest of the largest groups), or -1 if there were none.
+func (rules *Rules) signature(cards []int) ([]int, int) {
+	number_of_cards := map[int]int{}
+	number_of_wildcards := 0
+
+	for _, card := range cards {
+		if rules.is_wildcard(card) {
+			number_of_wildcards += 1
+		} else {
+			number_of_cards[card] += 1
+		}
+	}
+
+	signature := make([]int, 0, len(number_of_cards))
+	substitute := -1
+
+	for card, count := range number_of_cards {
+		signature = append(signature, count)
+
+		if substitute < 0 || count > number_of_cards[substitute] ||
+			(count == number_of_cards[substitute] && card > substitute) {
+			substitute = card
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(signature)))
+
+	if number_of_wildcards == 0 {
+		return signature, -1
+	}
+
+	if len(signature) == 0 {
+		// Nothing but wildcards: they become the strongest card there is.
+		return []int{number_of_wildcards}, len(rules.ranking) - 1
+	}
+
+	signature[0] += number_of_wildcards
+
+	return signature, substitute
+}
+
+func (rules *Rules) category(signature []int) Category {
+	i, found := sort.Find(len(rules.categories), func(i int) int {
+		return slices.Compare(signature, rules.categories[i].Signature)
+	})
+
+	if !found {
+		panic("signature " + signature_key(signature) + " has no category")
+	}
+
+	return rules.categories[i]
+}
+
+// Parses a line of the form "<cards> <bid>".
+func (rules *Rules) ParseHand(line string) (Hand, error) {
+	fields := fields_regex.FindAllStringIndex(line, -1)
+
+	if len(fields) != 2 {
+		return Hand{}, aoc.Expected(0, "\"<cards> <bid>\"", fmt.Sprintf("%d fields", len(fields)))
+	}
+
+	cards := line[fields[0][0]:fields[0][1]]
+	bid_string := line[fields[1][0]:fields[1][1]]
+	hand_string := []rune(cards)
+
+	if len(hand_string) != rules.HandSize {
+		return Hand{}, aoc.Expected(fields[0][0]+1, fmt.Sprintf("%d cards (rules %q)", rules.HandSize, rules.Name), fmt.Sprintf("%d", len(hand_string)))
+	}
+
+	bid, err := strconv.Atoi(bid_string)
+	if err != nil {
+		return Hand{}, aoc.Expected(fields[1][0]+1, "a bid that fits in an int", aoc.Found(bid_string))
+	}
+
+	hand := Hand{
+		Text:       cards,
+		Cards:      make([]int, rules.HandSize),
+		Bid:        bid,
+		Substitute: -1,
+	}
+
+	i := 0
+	for offset, char := range cards {
+		rank, ok := rules.ranking[char]
+		if !ok {
+			return Hand{}, aoc.Expected(fields[0][0]+offset+1, fmt.Sprintf("a card in %q", rules.Order), aoc.Found(string(char)))
+		}
+		hand.Cards[i] = rank
+		i++
+	}
+
+	hand.Signature, hand.Substitute = rules.signature(hand.Cards)
+	hand.Category = rules.category(hand.Signature)
+
+	return hand, nil
+}
+
+type ByHandStrength []Hand
+
+func (a ByHandStrength) Len() int { return len(a) }
+func (a ByHandStrength) Less(i, j int) bool {
+	// Only complication is if the two hand strengths are the same
+	if a[i].Category.Strength == a[j].Category.Strength {
+		// If they are the same, compare the cards in turn.
+		return slices.Compare(a[i].Cards, a[j].Cards) < 0
+	}
+
+	return a[i].Category.Strength < a[j].Category.Strength
+}
+func (a ByHandStrength) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+// Sorts the hands in place and returns the sum of rank times bid.
+func TotalWinnings(hands []Hand) int {
+	sort.Sort(ByHandStrength(hands))
+
+	total_winnings := 0
+
+	for rank, hand := range hands {
+		// We are using 1-indexing here.
+		total_winnings += (rank + 1) * hand.Bid
+	}
+
+	return total_winnings
+}
+
+// Describes why hand a ranks below hand b: either its category is weaker,
+// or the categories tie and the first differing card decides.
+func (rules *Rules) Decide(a Hand, b Hand) string {
+	if a.Category.Strength != b.Category.Strength {
+		return fmt.Sprintf("category: %s < %s", a.Category.Name, b.Category.Name)
+	}
+
+	order := []rune(rules.Order)
+
+	for k := range a.Cards {
+		if a.Cards[k] != b.Cards[k] {
+			return fmt.Sprintf("card %d: %c < %c", k, order[a.Cards[k]], order[b.Cards[k]])
+		}
+	}
+
+	return "identical hands"
+}
+
+// Writes the hands, which must already be sorted, with their category,
+// wildcard substitution, rank and bid contribution, followed by the reason
+// each hand beat the one ranked just below it.
+func (rules *Rules) Explain(w io.Writer, hands []Hand) {
+	order := []rune(rules.Order)
+
+	fmt.Fprintf(w, "%-5s %-*s %-16s %-10s %6s %10s\n", "rank", rules.HandSize, "hand", "category", "wildcards", "bid", "winnings")
+
+	for i, hand := range hands {
+		substitution := "-"
+		if hand.Substitute >= 0 {
+			substitution = fmt.Sprintf("-> %c", order[hand.Substitute])
+		}
+
+		fmt.Fprintf(w, "%-5d %-*s %-16s %-10s %6d %10d\n", i+1, rules.HandSize, hand.Text, hand.Category.Name, substitution, hand.Bid, (i+1)*hand.Bid)
+	}
+
+	fmt.Fprintln(w)
+
+	for i := 1; i < len(hands); i++ {
+		fmt.Fprintf(w, "%s beats %s: %s\n", hands[i].Text, hands[i-1].Text, rules.Decide(hands[i-1], hands[i]))
+	}
+}
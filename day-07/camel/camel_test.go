@@ -0,0 +1,260 @@
+package camel
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+var example = []string{
+	"32T3K 765",
+	"T55J5 684",
+	"KK677 28",
+	"KTJJT 220",
+	"QQQJA 483",
+}
+
+func compile(t *testing.T, rules Rules) Rules {
+	t.Helper()
+
+	if err := rules.Compile(); err != nil {
+		t.Fatalf("Compile(%q) failed: %v", rules.Name, err)
+	}
+
+	return rules
+}
+
+func parse_hand(t *testing.T, rules *Rules, line string) Hand {
+	t.Helper()
+
+	hand, err := rules.ParseHand(line)
+	if err != nil {
+		t.Fatalf("ParseHand(%q) under %s failed: %v", line, rules.Name, err)
+	}
+
+	return hand
+}
+
+func TestParseHand(t *testing.T) {
+	tests := []struct {
+		rules      Rules
+		line       string
+		category   string
+		substitute string
+	}{
+		{Part1, "32T3K 765", "one_pair", ""},
+		{Part1, "KTJJT 220", "two_pair", ""},
+		{Part1, "T55J5 684", "three_of_a_kind", ""},
+		{Part1, "23332 1", "full_house", ""},
+		{Part1, "AA8AA 1", "four_of_a_kind", ""},
+		{Part1, "AAAAA 1", "five_of_a_kind", ""},
+		{Part1, "23456 1", "high_card", ""},
+		{Part2, "T55J5 684", "four_of_a_kind", "5"},
+		{Part2, "KTJJT 220", "four_of_a_kind", "T"},
+		// With no largest group, the wildcard joins the strongest card.
+		{Part2, "2345J 1", "one_pair", "5"},
+		{Part2, "JJJJJ 1", "five_of_a_kind", "A"},
+		{Rules{Name: "seven", Order: "J23456789TQKA", Wildcards: "J", HandSize: 7}, "22JKKQQ 1", "3,2,2", "K"},
+	}
+
+	for _, test := range tests {
+		rules := compile(t, test.rules)
+
+		hand, err := rules.ParseHand(test.line)
+		if err != nil {
+			t.Errorf("ParseHand(%q) under %s failed: %v", test.line, rules.Name, err)
+			continue
+		}
+
+		if hand.Category.Name != test.category {
+			t.Errorf("ParseHand(%q) under %s is %s, want %s", test.line, rules.Name, hand.Category.Name, test.category)
+		}
+
+		substitute := ""
+		if hand.Substitute >= 0 {
+			substitute = string([]rune(rules.Order)[hand.Substitute])
+		}
+
+		if substitute != test.substitute {
+			t.Errorf("ParseHand(%q) under %s substitutes %q, want %q", test.line, rules.Name, substitute, test.substitute)
+		}
+	}
+}
+
+func TestParseHandErrors(t *testing.T) {
+	tests := []struct {
+		line   string
+		column int
+	}{
+		{"32T3K", 0},
+		{"32T3K 765 1", 0},
+		{"32T3 765", 1},
+		{"32X3K 765", 3},
+		{"32T3K bid", 7},
+	}
+
+	rules := compile(t, Part1)
+
+	for _, test := range tests {
+		_, err := rules.ParseHand(test.line)
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) {
+			t.Errorf("ParseHand(%q) = %v, want an input error", test.line, err)
+			continue
+		}
+
+		if input_err.Column != test.column {
+			t.Errorf("ParseHand(%q) points at column %d, want %d", test.line, input_err.Column, test.column)
+		}
+	}
+}
+
+func TestTotalWinnings(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		want  int
+	}{
+		{Part1, 6440},
+		{Part2, 5905},
+	}
+
+	for _, test := range tests {
+		rules := compile(t, test.rules)
+
+		hands := make([]Hand, len(example))
+		for i, line := range example {
+			hand, err := rules.ParseHand(line)
+			if err != nil {
+				t.Fatalf("ParseHand(%q) failed: %v", line, err)
+			}
+			hands[i] = hand
+		}
+
+		if got := TotalWinnings(hands); got != test.want {
+			t.Errorf("TotalWinnings under %s = %d, want %d", rules.Name, got, test.want)
+		}
+	}
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		a     string
+		b     string
+		want  string
+	}{
+		{Part1, "32T3K 1", "T55J5 1", "category: one_pair < three_of_a_kind"},
+		{Part1, "KTJJT 1", "KK677 1", "card 1: T < K"},
+		{Part1, "KK677 1", "KK776 1", "card 2: 6 < 7"},
+		{Part1, "KK677 1", "KK677 2", "identical hands"},
+		// The wildcard counts towards the category but is the weakest card.
+		{Part2, "T55J5 1", "QQQJA 1", "card 0: T < Q"},
+		{Part2, "JKKK2 1", "QQQQ2 1", "card 0: J < Q"},
+		{Part2, "KK677 1", "KTJJT 1", "category: two_pair < four_of_a_kind"},
+	}
+
+	for _, test := range tests {
+		rules := compile(t, test.rules)
+		a, b := parse_hand(t, &rules, test.a), parse_hand(t, &rules, test.b)
+
+		if got := rules.Decide(a, b); got != test.want {
+			t.Errorf("Decide(%s, %s) under %s = %q, want %q", a.Text, b.Text, rules.Name, got, test.want)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	rules := compile(t, Part2)
+
+	hands := make([]Hand, len(example))
+	for i, line := range example {
+		hands[i] = parse_hand(t, &rules, line)
+	}
+	sort.Sort(ByHandStrength(hands))
+
+	output := strings.Builder{}
+	rules.Explain(&output, hands)
+
+	want := `rank  hand  category         wildcards     bid   winnings
+1     32T3K one_pair         -             765        765
+2     KK677 two_pair         -              28         56
+3     T55J5 four_of_a_kind   -> 5          684       2052
+4     QQQJA four_of_a_kind   -> Q          483       1932
+5     KTJJT four_of_a_kind   -> T          220       1100
+
+KK677 beats 32T3K: category: one_pair < two_pair
+T55J5 beats KK677: category: two_pair < four_of_a_kind
+QQQJA beats T55J5: card 0: T < Q
+KTJJT beats QQQJA: card 0: Q < K
+`
+
+	if output.String() != want {
+		t.Errorf("Explain wrote\n%s\nwant\n%s", output.String(), want)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []Rules{
+		{Name: "empty hand", Order: "23456789TJQKA", HandSize: 0},
+		{Name: "repeated card", Order: "23456789TJQKAA", HandSize: 5},
+		{Name: "stray wildcard", Order: "23456789TQKA", Wildcards: "J", HandSize: 5},
+	}
+
+	for _, rules := range tests {
+		if err := rules.Compile(); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", rules.Name)
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	// The number of ways to split n cards into groups.
+	for size, want := range map[int]int{1: 1, 5: 7, 7: 15} {
+		rules := compile(t, Rules{Name: "sized", Order: "23456789TJQKA", HandSize: size})
+
+		categories := rules.Categories()
+		if len(categories) != want {
+			t.Errorf("hand size %d has %d categories, want %d", size, len(categories), want)
+		}
+
+		if last := categories[len(categories)-1]; len(last.Signature) != 1 || last.Signature[0] != size {
+			t.Errorf("hand size %d: strongest category is %v, want all one card", size, last.Signature)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	good := filepath.Join(dir, "good.json")
+	os.WriteFile(good, []byte(`{"name": "wild", "order": "J23456789TQKA", "wildcards": "J", "hand_size": 7}`), 0644)
+
+	rules, err := LoadRules(good)
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	if rules.Name != "wild" || rules.Wildcards != "J" || rules.HandSize != 7 {
+		t.Errorf("LoadRules = %+v", rules)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte("{\n  \"name\": \"wild\",\n  \"hand_size\": \"seven\"\n}"), 0644)
+
+	_, err = LoadRules(bad)
+
+	input_err := &aoc.InputError{}
+	if !errors.As(err, &input_err) {
+		t.Fatalf("LoadRules(bad) = %v, want an input error", err)
+	}
+
+	if input_err.Line != 3 {
+		t.Errorf("LoadRules(bad) points at line %d, want 3", input_err.Line)
+	}
+}
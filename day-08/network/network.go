@@ -0,0 +1,45 @@
+// Package network reads the day 8 maps: a route of left and right turns,
+// then one node per line. Both parts read the same grammar, in which node
+// names are three letters or digits.
+package network
+
+import (
+	"regexp"
+
+	"advent-of-code/aoc"
+)
+
+type Node struct {
+	Left  string
+	Right string
+}
+
+var node_regex = regexp.MustCompile(`^([0-9A-Z]{3}) = \(([0-9A-Z]{3}), ([0-9A-Z]{3})\)$`)
+var route_regex = regexp.MustCompile(`[^LR]`)
+
+// Parses a line of the form "AAA = (BBB, CCC)".
+func ParseNode(to_parse string) (string, Node, error) {
+	matches := node_regex.FindStringSubmatch(to_parse)
+
+	if len(matches) != 4 {
+		return "", Node{}, aoc.Expected(1, "\"<node> = (<left>, <right>)\" with three letter or digit names", aoc.Found(to_parse))
+	}
+
+	name := matches[1]
+	left := matches[2]
+	right := matches[3]
+
+	return name, Node{left, right}, nil
+}
+
+func ParseRoute(to_parse string) (string, error) {
+	if to_parse == "" {
+		return "", aoc.Expected(1, "a route of L and R", aoc.Found(to_parse))
+	}
+
+	if bad := route_regex.FindStringIndex(to_parse); bad != nil {
+		return "", aoc.Expected(bad[0]+1, "L or R", aoc.Found(to_parse[bad[0]:bad[1]]))
+	}
+
+	return to_parse, nil
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+	"errors"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func TestParseNode(t *testing.T) {
+	tests := []struct {
+		text   string
+		name   string
+		node   Node
+		column int
+	}{
+		{"AAA = (BBB, CCC)", "AAA", Node{"BBB", "CCC"}, 0},
+		{"11A = (11B, XXX)", "11A", Node{"11B", "XXX"}, 0},
+		// Both parts read the same names.
+		{"AAAA = (BBB, CCC)", "", Node{}, 1},
+		{"AA = (BBB, CCC)", "", Node{}, 1},
+		{"aaa = (bbb, ccc)", "", Node{}, 1},
+		{"AAA = BBB, CCC", "", Node{}, 1},
+	}
+
+	for _, test := range tests {
+		name, node, err := ParseNode(test.text)
+
+		if test.column == 0 {
+			if err != nil || name != test.name || node != test.node {
+				t.Errorf("ParseNode(%q) = %q, %+v, %v, want %q, %+v", test.text, name, node, err, test.name, test.node)
+			}
+			continue
+		}
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) || input_err.Column != test.column {
+			t.Errorf("ParseNode(%q) = %v, want an error at column %d", test.text, err, test.column)
+		}
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	tests := []struct {
+		text   string
+		column int
+	}{
+		{"LLR", 0},
+		{"", 1},
+		{"LRX", 3},
+		{"L R", 2},
+	}
+
+	for _, test := range tests {
+		route, err := ParseRoute(test.text)
+
+		if test.column == 0 {
+			if err != nil || route != test.text {
+				t.Errorf("ParseRoute(%q) = %q, %v", test.text, route, err)
+			}
+			continue
+		}
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) || input_err.Column != test.column {
+			t.Errorf("ParseRoute(%q) = %v, want an error at column %d", test.text, err, test.column)
+		}
+	}
+}
@@ -1,42 +1,22 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
 	"log"
-	"os"
-	"regexp"
 	"strings"
+
+	"advent-of-code/aoc"
+	"advent-of-code/day-08/network"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+
 // type Node struct {
 // 	path_length int
 // 	visited     bool
 // 	links       []string
 // }
 
-type Node struct {
-	left  string
-	right string
-}
-
-var node_regex = regexp.MustCompile(`([A-Z]*) = \(([A-Z]*), ([A-Z]*)\)`)
-
-func process_node(to_parse string) (string, Node) {
-	matches := node_regex.FindStringSubmatch(to_parse)
-
-	if len(matches) != 4 {
-		log.Fatal("Could not parse: ", to_parse)
-	}
-
-	name := matches[1]
-	left := matches[2]
-	right := matches[3]
-
-	return name, Node{left, right}
-}
-
-func follow_route(route string, nodes map[string]Node, starting_node string, ending_node string) int {
+func follow_route(route string, nodes map[string]network.Node, starting_node string, ending_node string) int {
 	// Returns the path length.
 
 	path_length := 0
@@ -46,9 +26,9 @@ func follow_route(route string, nodes map[string]Node, starting_node string, end
 		instruction := route[path_length%len(route)]
 
 		if instruction == 'L' {
-			current_node = nodes[current_node].left
+			current_node = nodes[current_node].Left
 		} else if instruction == 'R' {
-			current_node = nodes[current_node].right
+			current_node = nodes[current_node].Right
 		} else {
 			log.Fatal("Unknown instruction: ", instruction)
 		}
@@ -69,19 +49,23 @@ func follow_route(route string, nodes map[string]Node, starting_node string, end
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(8, 1, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	number_of_lines := 0
 	route := ""
-	nodes := make(map[string]Node)
+	nodes := make(map[string]network.Node)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
 		if number_of_lines == 0 {
-			route = text
+			var err error
+			route, err = network.ParseRoute(text)
+			aoc.Check(input.Wrap(err))
 			number_of_lines += 1
 			continue
 		}
@@ -90,29 +74,32 @@ func main() {
 			continue
 		}
 
-		name, node := process_node(text)
+		name, node, err := network.ParseNode(text)
+		aoc.Check(input.Wrap(err))
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-			fmt.Println("Parsed to: ", node)
-		}
+		parsing.Debug("Given: ", text)
+		parsing.Debug("Parsed to: ", node)
 
 		nodes[name] = node
 
 		number_of_lines += 1
 	}
 
-	if DEBUG {
-		fmt.Println("Route: ", route)
-	}
+	parsing.Debug("Route: ", route)
+
+	aoc.Check(input.Err())
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
+	for _, name := range []string{"AAA", "ZZZ"} {
+		if _, ok := nodes[name]; !ok {
+			aoc.Check(&aoc.InputError{File: input.File, Expected: "a node called " + name, Found: "none"})
+		}
 	}
 
+	run.Parsed()
+
 	// Now we can follow the route.
 	path_length := follow_route(route, nodes, "AAA", "ZZZ")
 
-	fmt.Println("Path length: ", path_length)
+	run.Answer("Path length", path_length)
 
 }
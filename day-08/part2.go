@@ -1,43 +1,24 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
 	"log"
-	"os"
-	"regexp"
 	"slices"
 	"strings"
+
+	"advent-of-code/aoc"
+	"advent-of-code/day-08/network"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+
 // type Node struct {
 // 	path_length int
 // 	visited     bool
 // 	links       []string
 // }
 
-type Node struct {
-	left  string
-	right string
-}
-
-var node_regex = regexp.MustCompile(`([0-9A-Z]*) = \(([0-9A-Z]*), ([0-9A-Z]*)\)`)
-
-func process_node(to_parse string) (string, Node) {
-	matches := node_regex.FindStringSubmatch(to_parse)
-
-	if len(matches) != 4 {
-		log.Fatal("Could not parse: ", to_parse)
-	}
-
-	name := matches[1]
-	left := matches[2]
-	right := matches[3]
-
-	return name, Node{left, right}
-}
-
-func follow_route(route string, nodes map[string]Node, starting_node string, ending_node string) []int {
+func follow_route(route string, nodes map[string]network.Node, starting_node string, ending_node string) []int {
 	// Returns the path length.
 
 	path_length := 0
@@ -52,9 +33,9 @@ func follow_route(route string, nodes map[string]Node, starting_node string, end
 			instruction := route[path_length%len(route)]
 
 			if instruction == 'L' {
-				current_node = nodes[current_node].left
+				current_node = nodes[current_node].Left
 			} else if instruction == 'R' {
-				current_node = nodes[current_node].right
+				current_node = nodes[current_node].Right
 			} else {
 				log.Fatal("Unknown instruction: ", instruction)
 			}
@@ -67,9 +48,9 @@ func follow_route(route string, nodes map[string]Node, starting_node string, end
 		instruction := route[path_length%len(route)]
 
 		if instruction == 'L' {
-			current_node = nodes[current_node].left
+			current_node = nodes[current_node].Left
 		} else if instruction == 'R' {
-			current_node = nodes[current_node].right
+			current_node = nodes[current_node].Right
 		} else {
 			log.Fatal("Unknown instruction: ", instruction)
 		}
@@ -80,7 +61,7 @@ func follow_route(route string, nodes map[string]Node, starting_node string, end
 	return overlaps
 }
 
-func follow_routes(route string, nodes map[string]Node, starting_nodes []string, ending_ndoes []string) [][]int {
+func follow_routes(route string, nodes map[string]network.Node, starting_nodes []string, ending_ndoes []string) [][]int {
 	// Returns the path length.
 
 	path_lengths := make([][]int, len(starting_nodes))
@@ -92,7 +73,7 @@ func follow_routes(route string, nodes map[string]Node, starting_nodes []string,
 	return path_lengths
 }
 
-func all_nodes_ending_in(graph map[string]Node, char string) []string {
+func all_nodes_ending_in(graph map[string]network.Node, char string) []string {
 	nodes := make([]string, 0)
 
 	for k := range graph {
@@ -132,19 +113,23 @@ func PrimeFactors(n int) (pfs []int) {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(8, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	number_of_lines := 0
 	route := ""
-	nodes := make(map[string]Node)
+	nodes := make(map[string]network.Node)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
 		if number_of_lines == 0 {
-			route = text
+			var err error
+			route, err = network.ParseRoute(text)
+			aoc.Check(input.Wrap(err))
 			number_of_lines += 1
 			continue
 		}
@@ -153,38 +138,32 @@ func main() {
 			continue
 		}
 
-		name, node := process_node(text)
+		name, node, err := network.ParseNode(text)
+		aoc.Check(input.Wrap(err))
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-			fmt.Println("Parsed to: ", node)
-		}
+		parsing.Debug("Given: ", text)
+		parsing.Debug("Parsed to: ", node)
 
 		nodes[name] = node
 
 		number_of_lines += 1
 	}
 
-	if DEBUG {
-		fmt.Println("Route: ", route)
-	}
+	parsing.Debug("Route: ", route)
 
-	if scanner.Err() != nil {
-		log.Fatal("No input provided.")
-	}
+	aoc.Check(input.Err())
+	run.Parsed()
 
 	starting_nodes := all_nodes_ending_in(nodes, "A")
 	ending_nodes := all_nodes_ending_in(nodes, "Z")
 
-	if DEBUG {
-		fmt.Println("Starting nodes: ", starting_nodes)
-		fmt.Println("Ending nodes: ", ending_nodes)
-	}
+	solving.Debug("Starting nodes: ", starting_nodes)
+	solving.Debug("Ending nodes: ", ending_nodes)
 
 	path_length := follow_routes(route, nodes, starting_nodes, ending_nodes)
 
 	for _, path := range path_length {
-		fmt.Println("Path lengths: ", path)
+		solving.Info("Path lengths: ", path)
 	}
 
 	unique_prime_factors := make([]int, 0)
@@ -197,13 +176,13 @@ func main() {
 			loop_lengths[i] = v - path[max(0, i-1)]
 		}
 
-		fmt.Println("Loop lengths: ", loop_lengths)
+		solving.Info("Loop lengths: ", loop_lengths)
 
 		this_loop_length := loop_lengths[1]
 
 		prime_factors := PrimeFactors(this_loop_length)
 
-		fmt.Println("Prime factors: ", prime_factors)
+		solving.Info("Prime factors: ", prime_factors)
 
 		for _, v := range prime_factors {
 			if !slices.Contains(unique_prime_factors, v) {
@@ -213,6 +192,6 @@ func main() {
 		}
 	}
 
-	fmt.Println("Unique prime factors: ", unique_prime_factors)
-	fmt.Println("Smallest factorization: ", lcm)
+	solving.Info("Unique prime factors: ", unique_prime_factors)
+	run.Answer("Smallest factorization", lcm)
 }
@@ -1,102 +1,64 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	"log"
+	"math/big"
 	"os"
-	"regexp"
-	"strconv"
-	"strings"
-)
-
-var extract_sequence_regex = regexp.MustCompile(`-?[0-9]*`)
-
-func extract_sequence(to_parse string) []int {
-	matches := extract_sequence_regex.FindAllString(strings.TrimSpace(to_parse), -1)
-
-	if len(matches) == 0 {
-		log.Fatal("Could not parse: ", to_parse)
-	}
-
-	sequence := make([]int, len(matches))
-
-	for i, match := range matches {
-		sequence[i], _ = strconv.Atoi(match)
-	}
 
-	return sequence
-}
+	"advent-of-code/aoc"
+	"advent-of-code/day-09/poly"
+)
 
-func find_differences(sequence []int) []int {
-	differences := make([]int, len(sequence)-1)
+var solving = aoc.Trace(aoc.Solve)
 
-	for i := 0; i < len(sequence)-1; i++ {
-		differences[i] = sequence[i+1] - sequence[i]
-	}
+var steps = flag.Int("k", 1, "how many steps after the end of each sequence to predict")
+var describe = flag.Bool("describe", false, "print the degree and coefficients of each fitted polynomial on stderr")
 
-	return differences
+func main() {
+	aoc.Main(9, 1, solve)
 }
 
-func are_all_values_equal(values []int) bool {
-	for i := 1; i < len(values); i++ {
-		if values[i] != values[0] {
-			return false
-		}
-	}
+func solve(run *aoc.Run) {
+	input := run.Input
 
-	return true
-}
+	total_next_values := new(big.Int)
 
-func are_all_values_zero(values []int) bool {
-	for i := 0; i < len(values); i++ {
-		if values[i] != 0 {
-			return false
-		}
-	}
+	sequences := [][]int{}
+	lines := []int{}
 
-	return true
-}
+	for input.Scan() {
+		sequence, err := poly.ParseSequence(input.Text())
+		aoc.Check(input.Wrap(err))
 
-func find_next_value_in_sequence(values []int) int {
-	fmt.Println("Finding next value in sequence: ", values)
-	if are_all_values_zero(values) {
-		return 0
+		sequences = append(sequences, sequence)
+		lines = append(lines, input.Line())
 	}
 
-	differences := find_differences(values)
-
-	return find_next_value_in_sequence(differences) + values[len(values)-1]
-}
-
-func main() {
-	DEBUG := true
-
-	scanner := bufio.NewScanner(os.Stdin)
+	aoc.Check(input.Err())
+	run.Parsed()
 
-	sequences := make([]string, 0)
-	next_values := make([]int, 0)
+	for i, sequence := range sequences {
+		polynomial := poly.Fit(sequence)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+		if !polynomial.Determined {
+			solving.Infof("Line %d: never reaches an all-zero difference row, so degree %d is a guess", lines[i], polynomial.Degree)
+		}
 
-		next_value := find_next_value_in_sequence(extract_sequence(text))
+		next_value := polynomial.Forward(*steps)
+		total_next_values.Add(total_next_values, next_value)
 
-		sequences = append(sequences, text)
-		next_values = append(next_values, next_value)
+		solving.Debug("Given: ", sequence)
+		solving.Debug("Next value: ", next_value)
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-			fmt.Println("Next value: ", next_value)
+		if *describe {
+			guess := ""
+			if !polynomial.Determined {
+				guess = " (not determined: no all-zero difference row)"
+			}
+			fmt.Fprintf(os.Stderr, "Line %d: degree %d%s, p(x) = %s\n", lines[i], polynomial.Degree, guess, polynomial)
 		}
 	}
 
-	total_next_values := 0
-
-	for _, v := range next_values {
-		total_next_values += v
-	}
-
-	fmt.Println("Total of next values: ", total_next_values)
-
+	run.Answer("Total of next values", total_next_values)
 }
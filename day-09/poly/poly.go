@@ -0,0 +1,212 @@
+// Package poly fits the minimal-degree polynomial through an integer
+// sequence (day 9) using Newton forward differences, and evaluates it
+// exactly any number of steps before or after the sequence.
+package poly
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"advent-of-code/aoc"
+)
+
+var fields_regex = regexp.MustCompile(`\S+`)
+
+// A polynomial p with p(0), p(1), ... p(Length-1) equal to the sequence it was
+// fitted to. Differences holds the leading entry of each row of the
+// difference table, Δ^j p(0), for j = 0..Degree.
+type Polynomial struct {
+	Degree      int
+	Length      int
+	Differences []*big.Int
+	// Whether the difference table reached an all-zero row, so that the
+	// sequence pins down the polynomial. False for sequences too short to
+	// tell, such as 1 2 4.
+	Determined bool
+}
+
+func ParseSequence(to_parse string) ([]int, error) {
+	fields := fields_regex.FindAllStringIndex(to_parse, -1)
+
+	if len(fields) == 0 {
+		return nil, aoc.Expected(1, "a sequence of numbers", aoc.Found(to_parse))
+	}
+
+	sequence := make([]int, len(fields))
+
+	for i, field := range fields {
+		value, err := strconv.Atoi(to_parse[field[0]:field[1]])
+		if err != nil {
+			return nil, aoc.Expected(field[0]+1, "a number that fits in an int", aoc.Found(to_parse[field[0]:field[1]]))
+		}
+		sequence[i] = value
+	}
+
+	return sequence, nil
+}
+
+func are_all_values_zero(values []*big.Int) bool {
+	for _, value := range values {
+		if value.Sign() != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Builds the difference table row by row until a row is all zeros. A
+// sequence too short to reach one, such as 1 2 4, gets the polynomial of
+// degree Length-1 through all its values, as if the empty row at the
+// bottom of the table were zeros, and is not Determined.
+func Fit(sequence []int) Polynomial {
+	row := make([]*big.Int, len(sequence))
+	for i, value := range sequence {
+		row[i] = big.NewInt(int64(value))
+	}
+
+	polynomial := Polynomial{Length: len(sequence), Degree: -1}
+
+	for len(row) > 0 {
+		if are_all_values_zero(row) {
+			polynomial.Determined = true
+			return polynomial
+		}
+
+		polynomial.Degree += 1
+		polynomial.Differences = append(polynomial.Differences, row[0])
+
+		differences := make([]*big.Int, len(row)-1)
+		for i := range differences {
+			differences[i] = new(big.Int).Sub(row[i+1], row[i])
+		}
+
+		row = differences
+	}
+
+	return polynomial
+}
+
+// Evaluates the Newton form sum_j Δ^j p(0) * C(x, j) at any integer x. The
+// generalised binomial C(x, j) is an integer for negative x too.
+func (p Polynomial) At(x int64) *big.Int {
+	total := new(big.Int)
+	binomial := big.NewInt(1)
+
+	for j, difference := range p.Differences {
+		if j > 0 {
+			// C(x, j) = C(x, j-1) * (x - j + 1) / j, which is always exact.
+			binomial.Mul(binomial, big.NewInt(x-int64(j)+1))
+			binomial.Quo(binomial, big.NewInt(int64(j)))
+		}
+
+		total.Add(total, new(big.Int).Mul(difference, binomial))
+	}
+
+	return total
+}
+
+// The value k steps after the last element of the sequence.
+func (p Polynomial) Forward(k int) *big.Int {
+	return p.At(int64(p.Length - 1 + k))
+}
+
+// The value k steps before the first element of the sequence.
+func (p Polynomial) Backward(k int) *big.Int {
+	return p.At(int64(-k))
+}
+
+// The coefficients of p in the monomial basis, constant term first, found
+// by expanding each falling factorial x(x-1)...(x-j+1) / j!.
+func (p Polynomial) Coefficients() []*big.Rat {
+	coefficients := make([]*big.Rat, len(p.Differences))
+	for i := range coefficients {
+		coefficients[i] = new(big.Rat)
+	}
+
+	// Coefficients of the current falling factorial, constant term first.
+	falling := []*big.Rat{big.NewRat(1, 1)}
+
+	for j, difference := range p.Differences {
+		if j > 0 {
+			// Multiply by (x - (j - 1)) / j.
+			next := make([]*big.Rat, len(falling)+1)
+			for i := range next {
+				next[i] = new(big.Rat)
+			}
+
+			shift := big.NewRat(int64(j-1), 1)
+			for i, c := range falling {
+				next[i+1].Add(next[i+1], c)
+				next[i].Sub(next[i], new(big.Rat).Mul(c, shift))
+			}
+
+			scale := big.NewRat(1, int64(j))
+			for i := range next {
+				next[i].Mul(next[i], scale)
+			}
+
+			falling = next
+		}
+
+		weight := new(big.Rat).SetInt(difference)
+		for i, c := range falling {
+			coefficients[i].Add(coefficients[i], new(big.Rat).Mul(c, weight))
+		}
+	}
+
+	return coefficients
+}
+
+// Writes p in the usual form, e.g. "3x^2 - 1/2x + 7".
+func (p Polynomial) String() string {
+	coefficients := p.Coefficients()
+
+	if len(coefficients) == 0 {
+		return "0"
+	}
+
+	terms := make([]string, 0)
+
+	for power := len(coefficients) - 1; power >= 0; power-- {
+		c := coefficients[power]
+
+		if c.Sign() == 0 {
+			continue
+		}
+
+		magnitude := new(big.Rat).Abs(c).RatString()
+		if power > 0 && magnitude == "1" {
+			magnitude = ""
+		}
+
+		term := magnitude
+		switch power {
+		case 0:
+		case 1:
+			term += "x"
+		default:
+			term += fmt.Sprintf("x^%d", power)
+		}
+
+		switch {
+		case len(terms) == 0 && c.Sign() < 0:
+			terms = append(terms, "-"+term)
+		case len(terms) == 0:
+			terms = append(terms, term)
+		case c.Sign() < 0:
+			terms = append(terms, "- "+term)
+		default:
+			terms = append(terms, "+ "+term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return "0"
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,107 @@
+package poly
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		sequence []int
+		degree   int
+		forward  int64
+		backward int64
+		formula  string
+		// Whether the sequence reached an all-zero difference row.
+		determined bool
+	}{
+		{[]int{0, 3, 6, 9, 12, 15}, 1, 18, -3, "3x", true},
+		{[]int{1, 3, 6, 10, 15, 21}, 2, 28, 0, "1/2x^2 + 3/2x + 1", true},
+		{[]int{10, 13, 16, 21, 30, 45}, 3, 68, 5, "1/3x^3 - x^2 + 11/3x + 10", true},
+		{[]int{7, 7, 7}, 0, 7, 7, "7", true},
+		{[]int{0, 0}, -1, 0, 0, "0", true},
+		// Too short to reach a zero row: the baseline answered these too,
+		// but they are flagged.
+		{[]int{1, 2}, 1, 3, 0, "x + 1", false},
+		{[]int{1, 2, 4}, 2, 7, 1, "1/2x^2 + 1/2x + 1", false},
+		{[]int{5}, 0, 5, 5, "5", false},
+	}
+
+	for _, test := range tests {
+		polynomial := Fit(test.sequence)
+
+		if polynomial.Degree != test.degree {
+			t.Errorf("Fit(%v).Degree = %d, want %d", test.sequence, polynomial.Degree, test.degree)
+		}
+
+		for x, value := range test.sequence {
+			if got := polynomial.At(int64(x)); got.Int64() != int64(value) {
+				t.Errorf("Fit(%v).At(%d) = %s, want %d", test.sequence, x, got, value)
+			}
+		}
+
+		if got := polynomial.Forward(1); got.Int64() != test.forward {
+			t.Errorf("Fit(%v).Forward(1) = %s, want %d", test.sequence, got, test.forward)
+		}
+
+		if got := polynomial.Backward(1); got.Int64() != test.backward {
+			t.Errorf("Fit(%v).Backward(1) = %s, want %d", test.sequence, got, test.backward)
+		}
+
+		if polynomial.Determined != test.determined {
+			t.Errorf("Fit(%v).Determined = %v, want %v", test.sequence, polynomial.Determined, test.determined)
+		}
+
+		if got := polynomial.String(); got != test.formula {
+			t.Errorf("Fit(%v).String() = %q, want %q", test.sequence, got, test.formula)
+		}
+	}
+}
+
+func TestForwardMoreSteps(t *testing.T) {
+	polynomial := Fit([]int{1, 4, 9, 16})
+
+	for k, want := range []int64{16, 25, 36, 49} {
+		if got := polynomial.Forward(k); got.Int64() != want {
+			t.Errorf("Forward(%d) = %s, want %d", k, got, want)
+		}
+	}
+
+	if got := polynomial.Backward(3); got.Int64() != 4 {
+		t.Errorf("Backward(3) = %s, want 4", got)
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	tests := []struct {
+		text     string
+		sequence []int
+		wrong    bool
+	}{
+		{"0 3 6", []int{0, 3, 6}, false},
+		{"  -4\t2  ", []int{-4, 2}, false},
+		{"", nil, true},
+		{"1 two 3", nil, true},
+		{"99999999999999999999", nil, true},
+	}
+
+	for _, test := range tests {
+		sequence, err := ParseSequence(test.text)
+
+		if test.wrong {
+			if err == nil {
+				t.Errorf("ParseSequence(%q) = %v, want an error", test.text, sequence)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseSequence(%q) failed: %v", test.text, err)
+			continue
+		}
+
+		if !slices.Equal(sequence, test.sequence) {
+			t.Errorf("ParseSequence(%q) = %v, want %v", test.text, sequence, test.sequence)
+		}
+	}
+}
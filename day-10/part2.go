@@ -1,13 +1,20 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
+	"io"
 	"slices"
 	"strings"
+
+	"advent-of-code/aoc"
+	"advent-of-code/search"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+var rendering = aoc.Trace(aoc.Render)
+
 // | is a vertical pipe connecting north and south.
 // - is a horizontal pipe connecting east and west.
 // L is a 90-degree bend connecting north and east.
@@ -132,193 +139,142 @@ func string_to_node(symbol string) Node {
 	}
 }
 
-func line_to_nodes(line string) []Node {
+func line_to_nodes(line string) ([]Node, error) {
 	line = strings.TrimSpace(line)
 
 	nodes := make([]Node, len(line))
 
 	for i, symbol := range line {
+		if _, ok := direction_map[string(symbol)]; !ok {
+			return nil, aoc.Expected(i+1, "one of |-LJ7F.S", aoc.Found(string(symbol)))
+		}
+
 		nodes[i] = string_to_node(string(symbol))
 	}
 
-	return nodes
+	return nodes, nil
 }
 
-func render_node_grid(nodes [][]Node) {
+// The sketch must have exactly one starting position.
+func check_start(nodes [][]Node, file string) error {
+	starts := 0
+
+	for _, row := range nodes {
+		for _, node := range row {
+			if node.symbol == "S" {
+				starts++
+			}
+		}
+	}
+
+	if starts != 1 {
+		return &aoc.InputError{File: file, Expected: "exactly one S", Found: fmt.Sprint(starts)}
+	}
+
+	return nil
+}
+
+func render_node_grid(output io.Writer, nodes [][]Node) {
 	for _, row := range nodes {
 		for _, node := range row {
 			if node.visited {
-				fmt.Print(color_red, node.render, color_none)
+				fmt.Fprint(output, color_red, node.render, color_none)
 			} else if node.outside {
-				fmt.Print(color_green, node.render, color_none)
+				fmt.Fprint(output, color_green, node.render, color_none)
 			} else if node.inside {
-				fmt.Print(color_cyan, node.render, color_none)
+				fmt.Fprint(output, color_cyan, node.render, color_none)
 			} else {
-				fmt.Print(node.render)
+				fmt.Fprint(output, node.render)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(output)
 	}
 }
 
-func render_node_grid_distances(nodes [][]Node) {
+func render_node_grid_distances(output io.Writer, nodes [][]Node) {
 	for _, row := range nodes {
 		for _, node := range row {
 			if node.visited {
-				fmt.Print(color_red)
+				fmt.Fprint(output, color_red)
 				if node.distance > 9 {
-					fmt.Print("X")
+					fmt.Fprint(output, "X")
 				} else {
-					fmt.Print(node.distance)
+					fmt.Fprint(output, node.distance)
 				}
-				fmt.Print()
+				fmt.Fprint(output)
 			} else {
-				fmt.Print("N")
+				fmt.Fprint(output, "N")
 			}
 
-			fmt.Print(color_none)
+			fmt.Fprint(output, color_none)
 		}
-		fmt.Println()
+		fmt.Fprintln(output)
 	}
 }
 
-func find_connections(nodes [][]Node) {
+func connects_back(node Node, direction []int) bool {
+	for _, connection := range node.connections {
+		if slices.Equal(connection, []int{direction[0] * -1, direction[1] * -1}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Breadth first from S along the pipes. Two tiles are only joined if each
+// one's pipe points at the other, so this follows the loop and nothing
+// else, and every tile's distance is the shorter way round.
+func find_connections(ctx context.Context, nodes [][]Node) {
 	// First, find the node with the S symbol.
-	beginning_x := 1
-	beginning_y := 1
+	beginning := [2]int{-1, -1}
 
 	for y, row := range nodes {
 		for x, node := range row {
 			if node.symbol == "S" {
-				beginning_x = x
-				beginning_y = y
+				beginning = [2]int{x, y}
 			}
 		}
 	}
 
-	for core_iteration := 0; core_iteration < 2; core_iteration++ {
-		start_x := beginning_x
-		start_y := beginning_y
+	if beginning[0] < 0 {
+		solving.Info("No starting position!")
+		return
+	}
 
-		prev_x := beginning_x
-		prev_y := beginning_y
+	neighbours := func(position [2]int) []search.Edge[[2]int] {
+		edges := make([]search.Edge[[2]int], 0, 2)
 
-		// Reset the visits
-		for y, row := range nodes {
-			for x := range row {
-				nodes[y][x].visited = false
+		for _, direction := range nodes[position[1]][position[0]].connections {
+			if len(direction) == 0 {
+				continue
 			}
-		}
 
-		current_symbol := "NONE"
-		nodes[start_y][start_x].visited = true
-		nodes[start_y][start_x].distance = 0
+			new_x := position[0] + direction[0]
+			new_y := position[1] + direction[1]
 
-		iterations := 0
-
-		// fmt.Println("Starting node: ", nodes[start_y][start_x])
-
-		for current_symbol != "S" {
-			// fmt.Println("Current symbol: ", current_symbol)
-			current_node := nodes[start_y][start_x]
-
-			for direction_id, direction := range current_node.connections {
-				// fmt.Println("Iteration: ", iterations)
-
-				if iterations > 250000 {
-					fmt.Println("Too many iterations!")
-					current_symbol = "S"
-					break
-				} else {
-					iterations++
-				}
-
-				if current_node.used[direction_id] && current_node.symbol == "S" {
-					continue
-				}
-				// fmt.Println("Checking direction: ", direction, "Have symbol: ", current_symbol)
-				// Does direction take us out of bounds?
-				new_x := start_x + direction[0]
-				new_y := start_y + direction[1]
-
-				if new_x < 0 || new_y < 0 || new_x >= len(nodes[0]) || new_y >= len(nodes) {
-					// fmt.Println("Out of bounds")
-					continue
-				}
-
-				if new_x == prev_x && new_y == prev_y {
-					continue
-				}
-
-				// Does the node we are going to have a symbol that accepts our connection?
-				allowed := false
-
-				for _, connection := range nodes[new_y][new_x].connections {
-					// fmt.Println("Checking connection: ", connection, "Against: ", []int{direction[0] * -1, direction[1] * -1})
-					if slices.Equal(connection, []int{direction[0] * -1, direction[1] * -1}) {
-						// fmt.Println("Found connection!")
-						allowed = true
-						break
-					}
-				}
-
-				if !allowed {
-					continue
-				}
-
-				// Is this new node the source and I have a large distance?
-				if nodes[new_y][new_x].symbol == "S" && nodes[start_y][start_x].distance >= 1 {
-					current_symbol = "S"
-					break
-				}
-
-				// Is this new node unvisited?
-				if nodes[new_y][new_x].visited {
-					// fmt.Println("Already visited...")
-					continue
-				}
-
-				// symbols := make([][]string, 3)
-
-				// for row_id := 0; row_id < 3; row_id++ {
-				// 	symbols[row_id] = []string{" ", " ", " "}
-				// }
-
-				// symbols[1][1] = current_node.render
-				// symbols[1+direction[1]][1+direction[0]] = nodes[new_y][new_x].render
-
-				// fmt.Println("We think that the following symbols are connected: ")
-				// for _, row := range symbols {
-				// 	fmt.Println(row)
-				// }
-
-				// We used this connection!
-				nodes[start_y][start_x].used[direction_id] = true
-
-				// Update our current position.
-				prev_x = start_x
-				prev_y = start_y
-
-				start_x = new_x
-				start_y = new_y
-
-				if nodes[start_y][start_x].distance == -1 {
-					nodes[start_y][start_x].distance = current_node.distance + 1
-				} else {
-					// fmt.Println("Found a shorter distance!", nodes[start_y][start_x].distance, current_node.distance+1)
-					nodes[start_y][start_x].distance = min(current_node.distance+1, nodes[start_y][start_x].distance)
-				}
-
-				current_symbol = nodes[start_y][start_x].symbol
-				nodes[start_y][start_x].visited = true
+			if new_y < 0 || new_y >= len(nodes) || new_x < 0 || new_x >= len(nodes[new_y]) {
+				continue
+			}
 
-				// We found the connection! Onto the next symbol.
-				break
+			if connects_back(nodes[new_y][new_x], direction) {
+				edges = append(edges, search.Edge[[2]int]{To: [2]int{new_x, new_y}, Cost: 1})
 			}
 		}
+
+		return edges
 	}
 
-	return
+	result := search.Search(search.Problem[[2]int]{
+		Starts:     [][2]int{beginning},
+		Neighbours: neighbours,
+		Context:    ctx,
+	})
+
+	for position, distance := range result.Costs() {
+		nodes[position[1]][position[0]].distance = distance
+		nodes[position[1]][position[0]].visited = true
+	}
 }
 
 // Watershed around the edges of the map.
@@ -460,24 +416,29 @@ func extract_watershed_status(nodes [][]Node, render_array [][]uint8) int {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(10, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	nodes := make([][]Node, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
-		node := line_to_nodes(text)
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+		node, err := line_to_nodes(text)
+		aoc.Check(input.Wrap(err))
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-		}
+		parsing.Debug("Given: ", text)
 
 		nodes = append(nodes, node)
 	}
 
-	find_connections(nodes)
+	aoc.Check(input.Err())
+	aoc.Check(check_start(nodes, input.File))
+	run.Parsed()
+
+	find_connections(run.Context(), nodes)
 
 	// Now watershed
 
@@ -487,8 +448,8 @@ func main() {
 
 	number_unvisited := label_unvisited(nodes)
 
-	fmt.Println("Number of unvisited nodes: ", number_unvisited)
-	fmt.Println("Warning - do not use this number - it does not watershed correctly into literal corner cases.")
+	solving.Info("Number of unvisited nodes: ", number_unvisited)
+	solving.Info("Warning - do not use this number - it does not watershed correctly into literal corner cases.")
 
 	rendered_array := render_to_2d_array(nodes)
 	watershed_all_edges_integers(rendered_array)
@@ -502,10 +463,12 @@ func main() {
 	// 	}
 	// }
 
-	if DEBUG {
-		render_node_grid(nodes)
+	if rendering.Enabled(aoc.Debug) {
+		done := run.Time(aoc.Render)
+		render_node_grid(rendering.Writer(aoc.Debug), nodes)
+		done()
 	}
 
-	fmt.Println("Number of unvisited nodes (true): ", number_unvisited)
+	run.Answer("Number of unvisited nodes (true)", number_unvisited)
 
 }
@@ -1,22 +1,39 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	"os"
+	"log"
+	"slices"
+	"strconv"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+
 type Galaxy struct {
-	x       int
-	y       int
-	id      int
-	shift_x int
-	shift_y int
+	x  int
+	y  int
+	id int
 }
 
-// This is 2 for part 1, 1000000 for part 2
-var SHIFT_FACTOR = 1000000
+// Both parts expand every empty row and column by the same amount; the
+// factors are kept separate so other expansions can be tried.
+type Expansion struct {
+	name string
+	// The puzzle part this answers, or 0 for a custom expansion.
+	part          int
+	row_factor    int
+	column_factor int
+}
+
+var PARTS = []Expansion{
+	{"Part 1", 1, 2, 2},
+	{"Part 2", 2, 1000000, 1000000},
+}
 
 func intAbs(x int) int {
 	if x < 0 {
@@ -35,7 +52,7 @@ func extract_galaxies(grid []string) []Galaxy {
 	for y, row := range grid {
 		for x, column := range row {
 			if string(column) == "#" {
-				galaxy := Galaxy{x, y, len(galaxies), 0, 0}
+				galaxy := Galaxy{x, y, len(galaxies)}
 				galaxies = append(galaxies, galaxy)
 			}
 		}
@@ -44,163 +61,171 @@ func extract_galaxies(grid []string) []Galaxy {
 	return galaxies
 }
 
-func all_pair_distances(galaxies []Galaxy) []int {
-	distances := make([]int, 0)
-
-	max_extract_id := 0
-
-	for i, a := range galaxies {
+// For each coordinate in [0, size), the coordinate it ends up at once every
+// empty line before it has been widened to factor lines.
+func expanded_coordinates(occupied []bool, factor int) []int {
+	expanded := make([]int, len(occupied))
+	empty_so_far := 0
 
-		max_extract_id = i
+	for i, is_occupied := range occupied {
+		expanded[i] = i + empty_so_far*(factor-1)
 
-		for j, b := range galaxies {
-			if i == j {
-				continue
-			}
-
-			if j > max_extract_id {
-				continue
-			}
-
-			distances = append(distances, manhattan_norm(a, b))
+		if !is_occupied {
+			empty_so_far += 1
 		}
 	}
 
-	return distances
+	return expanded
 }
 
-func insert_extra_space_x(galaxies []Galaxy) {
-	inject_space_at := make([]int, 0)
-
-	maximal_x := 0
+// Returns copies of the galaxies with every empty row widened to
+// row_factor rows and every empty column to column_factor columns.
+func expand(galaxies []Galaxy, width int, height int, row_factor int, column_factor int) []Galaxy {
+	occupied_columns := make([]bool, width)
+	occupied_rows := make([]bool, height)
 
 	for _, galaxy := range galaxies {
-		if galaxy.x > maximal_x {
-			maximal_x = galaxy.x
-		}
+		occupied_columns[galaxy.x] = true
+		occupied_rows[galaxy.y] = true
 	}
 
-	for x := 0; x <= maximal_x; x++ {
-		no_galaxies := true
-
-		for _, galaxy := range galaxies {
-			if galaxy.x == x {
-				no_galaxies = false
-				break
-			}
-		}
-
-		if !no_galaxies {
-			continue
-		} else {
-			inject_space_at = append(inject_space_at, x)
-		}
-	}
+	new_x := expanded_coordinates(occupied_columns, column_factor)
+	new_y := expanded_coordinates(occupied_rows, row_factor)
 
-	for _, x := range inject_space_at {
-		for i, galaxy := range galaxies {
-			if galaxy.x > x {
-				galaxies[i].shift_x += 1
-			}
-		}
-	}
+	expanded := make([]Galaxy, len(galaxies))
 
-	// Apply the shift
 	for i, galaxy := range galaxies {
-		galaxies[i].x += galaxy.shift_x * (SHIFT_FACTOR - 1)
+		expanded[i] = Galaxy{new_x[galaxy.x], new_y[galaxy.y], galaxy.id}
 	}
 
-	return
+	return expanded
 }
 
-func insert_extra_space_y(galaxies []Galaxy) {
-	inject_space_at := make([]int, 0)
+// Sum of |a - b| over every unordered pair. Once sorted, each value is
+// larger than all of the ones before it, so it contributes
+// value * i - (sum of the previous i values).
+func sum_of_pairwise_differences(values []int) int {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
 
-	maximal_y := 0
+	total := 0
+	prefix := 0
 
-	for _, galaxy := range galaxies {
-		if galaxy.x > maximal_y {
-			maximal_y = galaxy.y
-		}
+	for i, value := range sorted {
+		total += value*i - prefix
+		prefix += value
 	}
 
-	for y := 0; y <= maximal_y; y++ {
-		no_galaxies := true
+	return total
+}
 
-		for _, galaxy := range galaxies {
-			if galaxy.y == y {
-				no_galaxies = false
-				break
-			}
-		}
+// Manhattan distance splits into independent x and y parts, so the sum over
+// all pairs is just the sum of the two per-axis sums.
+func sum_of_pair_distances(galaxies []Galaxy) int {
+	xs := make([]int, len(galaxies))
+	ys := make([]int, len(galaxies))
 
-		if !no_galaxies {
-			continue
-		} else {
-			inject_space_at = append(inject_space_at, y)
-		}
+	for i, galaxy := range galaxies {
+		xs[i] = galaxy.x
+		ys[i] = galaxy.y
 	}
 
-	for _, y := range inject_space_at {
-		for i, galaxy := range galaxies {
-			if galaxy.y > y {
-				galaxies[i].shift_y += 1
-			}
-		}
+	return sum_of_pairwise_differences(xs) + sum_of_pairwise_differences(ys)
+}
+
+func parse_pair(to_parse string, n_galaxies int) (int, int, error) {
+	split := strings.Split(to_parse, ",")
+
+	if len(split) != 2 {
+		return 0, 0, &aoc.InputError{File: "-pair", Expected: "a pair of galaxy ids like 4,8", Found: aoc.Found(to_parse), Text: to_parse}
 	}
 
-	// Apply the shift
-	for i, galaxy := range galaxies {
-		galaxies[i].y += galaxy.shift_y * (SHIFT_FACTOR - 1)
+	ids := [2]int{}
+	column := 1
+
+	for i, id_string := range split {
+		id, err := strconv.Atoi(strings.TrimSpace(id_string))
+		if err != nil || id < 0 || id >= n_galaxies {
+			expected := fmt.Sprintf("a galaxy id from 0 to %d", n_galaxies-1)
+			return 0, 0, &aoc.InputError{File: "-pair", Column: column, Expected: expected, Found: aoc.Found(id_string), Text: to_parse}
+		}
+		ids[i] = id
+		column += len(id_string) + 1
 	}
 
-	return
+	return ids[0], ids[1], nil
 }
 
+var row_factor = flag.Int("row-factor", 0, "also run with each empty row widened to this many rows")
+var column_factor = flag.Int("column-factor", 0, "also run with each empty column widened to this many columns")
+var pair = flag.String("pair", "", "print the distance between two galaxy ids (as listed in the debug output), e.g. 4,8")
+
 func main() {
-	DEBUG := true
+	aoc.Main(11, 1, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	grid := make([]string, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+		aoc.Check(input.Wrap(aoc.GridRow(text, ".#", -1)))
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-		}
+		parsing.Debug("Given: ", text)
 
 		grid = append(grid, text)
 	}
 
+	aoc.Check(input.Err())
+	run.Parsed()
+
 	galaxies := extract_galaxies(grid)
 
-	if DEBUG {
-		fmt.Println("Galaxies:")
+	if parsing.Enabled(aoc.Debug) {
+		parsing.Debug("Galaxies:")
 		for _, galaxy := range galaxies {
-			fmt.Println(galaxy)
+			parsing.Debug(galaxy)
 		}
 	}
 
-	// Shift the galaxies
-	insert_extra_space_x(galaxies)
-	insert_extra_space_y(galaxies)
+	width := 0
+	for _, row := range grid {
+		width = max(width, len(row))
+	}
 
-	distances := all_pair_distances(galaxies)
+	parts := PARTS
 
-	if DEBUG {
-		fmt.Println("Distances:")
-		fmt.Println(distances)
-		fmt.Println("Found", len(distances), "pairs")
-	}
+	if *row_factor > 0 || *column_factor > 0 {
+		if *row_factor <= 0 || *column_factor <= 0 {
+			log.Fatal("Both -row-factor and -column-factor must be given")
+		}
 
-	total_distances := 0
+		parts = append(parts, Expansion{"Custom", 0, *row_factor, *column_factor})
+	}
 
-	for _, distance := range distances {
-		total_distances += distance
+	pair_a, pair_b := -1, -1
+	if *pair != "" {
+		var err error
+		pair_a, pair_b, err = parse_pair(*pair, len(galaxies))
+		aoc.Check(err)
 	}
 
-	fmt.Println(total_distances)
+	for _, part := range parts {
+		expanded := expand(galaxies, width, len(grid), part.row_factor, part.column_factor)
+		run.Part = part.part
 
+		if pair_a >= 0 {
+			distance := manhattan_norm(expanded[pair_a], expanded[pair_b])
+			run.Answer(fmt.Sprintf("%s distance between galaxy %d and galaxy %d", part.name, pair_a, pair_b), distance)
+			continue
+		}
+
+		total_distances := sum_of_pair_distances(expanded)
+
+		solving.Debug(part.name, "expansion factors (rows, columns):", part.row_factor, part.column_factor)
+
+		run.Answer(part.name, total_distances)
+	}
 }
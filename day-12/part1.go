@@ -1,13 +1,15 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var solving = aoc.Trace(aoc.Solve)
+
 const (
 	UNKNOWN     = uint8(0)
 	OPERATIONAL = uint8(1)
@@ -20,24 +22,35 @@ var string_to_status = map[string]uint8{
 	"#": BROKEN,
 }
 
-func parse_row(row string) ([]uint8, []uint8) {
+func parse_row(row string) ([]uint8, []uint8, error) {
 	status := make([]uint8, 0)
 	pattern := make([]uint8, 0)
 
-	splits := strings.Split(strings.TrimSpace(row), " ")
-	tokens := splits[0]
-	patterns := strings.Split(splits[1], ",")
+	tokens, pattern_string, found := strings.Cut(row, " ")
+	if !found {
+		return nil, nil, aoc.Expected(0, "\"<springs> <group sizes>\"", aoc.Found(row))
+	}
 
-	for _, column := range tokens {
-		status = append(status, string_to_status[string(column)])
+	for i, column := range tokens {
+		value, ok := string_to_status[string(column)]
+		if !ok {
+			return nil, nil, aoc.Expected(i+1, "one of ?.#", aoc.Found(string(column)))
+		}
+		status = append(status, value)
 	}
 
-	for _, value := range patterns {
-		v, _ := strconv.Atoi(value)
+	column := len(tokens) + 2
+
+	for _, value := range strings.Split(pattern_string, ",") {
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 || v > 255 {
+			return nil, nil, aoc.Expected(column, "a group size from 1 to 255", aoc.Found(value))
+		}
 		pattern = append(pattern, uint8(v))
+		column += len(value) + 1
 	}
 
-	return status, pattern
+	return status, pattern, nil
 }
 
 func matches_pattern(row []uint8, pattern []uint8) bool {
@@ -173,31 +186,51 @@ func replace_and_continue(
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(12, 1, solve)
+}
+
+func solve(run *aoc.Run) {
+	input := run.Input
+
+	all_rows := make([]string, 0)
+	all_statuses := make([][]uint8, 0)
+	all_patterns := make([][]uint8, 0)
 
-	scanner := bufio.NewScanner(os.Stdin)
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+
+		status, pattern, err := parse_row(text)
+		aoc.Check(input.Wrap(err))
+
+		all_rows = append(all_rows, text)
+		all_statuses = append(all_statuses, status)
+		all_patterns = append(all_patterns, pattern)
+	}
+
+	aoc.Check(input.Err())
+	run.Parsed()
 
 	all_matches := 0
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	ctx := run.Context()
 
-		status, pattern := parse_row(text)
+	for i, text := range all_rows {
+		if ctx.Err() != nil {
+			solving.Info("Stopped after ", i, " rows: ", ctx.Err())
+			break
+		}
 
 		var total_matches *int
 		total_matches = new(int)
 		*total_matches = 0
-		replace_and_continue(status, 0, pattern, total_matches)
+		replace_and_continue(all_statuses[i], 0, all_patterns[i], total_matches)
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-			fmt.Println("Parsed to: ", status, pattern)
-			fmt.Println("Total matches: ", *total_matches)
-		}
+		solving.Debug("Given: ", text)
+		solving.Debug("Parsed to: ", all_statuses[i], all_patterns[i])
+		solving.Debug("Total matches: ", *total_matches)
 
 		all_matches += *total_matches
-
 	}
 
-	fmt.Println("Total matches: ", all_matches)
+	run.Answer("Total matches", all_matches)
 }
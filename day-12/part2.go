@@ -1,13 +1,19 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
-	"os"
+	"log"
+	"slices"
 	"strconv"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+
 const (
 	UNKNOWN     = uint8(0)
 	OPERATIONAL = uint8(1)
@@ -20,35 +26,44 @@ var string_to_status = map[string]uint8{
 	"#": BROKEN,
 }
 
-func parse_row(row string) ([]uint8, []uint8) {
+func parse_row(row string) ([]uint8, []uint8, error) {
 	status := make([]uint8, 0)
 	pattern := make([]uint8, 0)
 
-	splits := strings.Split(strings.TrimSpace(row), " ")
-	tokens := splits[0]
-	pattern_string := splits[1]
-
-	// Unfold them.
-	new_tokens := "" + tokens
-	new_patterns := "" + pattern_string
+	tokens, pattern_string, found := strings.Cut(row, " ")
+	if !found {
+		return nil, nil, aoc.Expected(0, "\"<springs> <group sizes>\"", aoc.Found(row))
+	}
 
-	for i := 0; i < 4; i++ {
-		new_tokens = new_tokens + "?" + tokens
-		new_patterns = new_patterns + "," + pattern_string
+	for i, column := range tokens {
+		value, ok := string_to_status[string(column)]
+		if !ok {
+			return nil, nil, aoc.Expected(i+1, "one of ?.#", aoc.Found(string(column)))
+		}
+		status = append(status, value)
 	}
 
-	patterns := strings.Split(new_patterns, ",")
+	column := len(tokens) + 2
 
-	for _, column := range new_tokens {
-		status = append(status, string_to_status[string(column)])
+	for _, value := range strings.Split(pattern_string, ",") {
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 || v > 255 {
+			return nil, nil, aoc.Expected(column, "a group size from 1 to 255", aoc.Found(value))
+		}
+		pattern = append(pattern, uint8(v))
+		column += len(value) + 1
 	}
 
-	for _, value := range patterns {
-		v, _ := strconv.Atoi(value)
-		pattern = append(pattern, uint8(v))
+	// Unfold them.
+	new_status := slices.Clone(status)
+	new_pattern := slices.Clone(pattern)
+
+	for i := 1; i < *unfold; i++ {
+		new_status = append(append(new_status, UNKNOWN), status...)
+		new_pattern = append(new_pattern, pattern...)
 	}
 
-	return status, pattern
+	return new_status, new_pattern, nil
 }
 
 func to_string(row []uint8) string {
@@ -253,39 +268,55 @@ func consume_all(
 	return total_matches
 }
 
-func parse_and_return(row string) int {
-	status, pattern := parse_row(row)
-	matches := consume_all(status, pattern)
-	return matches
-}
+var unfold = flag.Int("unfold", 5, "how many copies of each row to join; 1 answers part 1")
 
 func main() {
-	DEBUG := true
+	aoc.Main(12, 2, solve)
+}
+
+func solve(run *aoc.Run) {
+	input := run.Input
 
-	scanner := bufio.NewScanner(os.Stdin)
+	if *unfold < 1 {
+		log.Fatal("-unfold must be at least 1")
+	}
 
 	all_rows := make([]string, 0)
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	all_statuses := make([][]uint8, 0)
+	all_patterns := make([][]uint8, 0)
+
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+
+		status, pattern, err := parse_row(text)
+		aoc.Check(input.Wrap(err))
 
 		all_rows = append(all_rows, text)
+		all_statuses = append(all_statuses, status)
+		all_patterns = append(all_patterns, pattern)
 	}
 
-	if DEBUG {
-		fmt.Println("Rows: ", all_rows)
-	}
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	parsing.Debug("Rows: ", all_rows)
 
 	// num_matches := make(chan int, len(all_rows))
 
 	all_matches := 0
 
-	for _, row := range all_rows {
-		these_matches := parse_and_return(row)
-		if DEBUG {
-			fmt.Println("Total matches: ", these_matches)
+	ctx := run.Context()
+
+	for i := range all_rows {
+		if ctx.Err() != nil {
+			solving.Info("Stopped after ", i, " rows: ", ctx.Err())
+			break
 		}
+
+		these_matches := consume_all(all_statuses[i], all_patterns[i])
+		solving.Debug("Total matches: ", these_matches)
 		all_matches += these_matches
 	}
 
-	fmt.Println("Total matches: ", all_matches)
+	run.Answer("Total matches", all_matches)
 }
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
-	"os"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var solving = aoc.Trace(aoc.Solve)
+
 func find_vertical_reflection_points(grid []string) []int {
 	// Find all points in the array where there is a vertical reflection
 	// (i.e. all rows above mirror those below.
@@ -72,52 +73,68 @@ func find_horizontal_reflection_points(grid []string) []int {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(13, 1, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
+	grids := make([][]string, 0)
 	grid := make([]string, 0)
 
 	summary := 0
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
 		if text == "" {
-			// Process grid and move on.
+			// End of a grid.
 
 			if len(grid) < 2 {
 				grid = make([]string, 0)
 				continue
 			}
 
-			horizontal := find_horizontal_reflection_points(grid)
-			vertical := find_vertical_reflection_points(grid)
-
-			if DEBUG {
-				fmt.Println("Grid:")
-				for i, line := range grid {
-					fmt.Println(line, i)
-				}
-				fmt.Println("Horizontal reflection points: ", horizontal)
-				fmt.Println("Verical reflection points: ", vertical)
-			}
-
-			for _, row := range horizontal {
-				summary += 1 * (row + 1)
-			}
-
-			for _, column := range vertical {
-				summary += 100 * (column + 1)
-			}
+			grids = append(grids, grid)
 
 			// Reset grid
 			grid = make([]string, 0)
 		} else {
 			// Add to grid.
+			width := -1
+			if len(grid) > 0 {
+				width = len(grid[0])
+			}
+			aoc.Check(input.Wrap(aoc.GridRow(text, ".#", width)))
+
 			grid = append(grid, text)
 		}
 	}
 
-	fmt.Println("Summary:", summary)
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	for _, grid := range grids {
+		horizontal := find_horizontal_reflection_points(grid)
+		vertical := find_vertical_reflection_points(grid)
+
+		if solving.Enabled(aoc.Debug) {
+			solving.Debug("Grid:")
+			for i, line := range grid {
+				solving.Debug(line, i)
+			}
+			solving.Debug("Horizontal reflection points: ", horizontal)
+			solving.Debug("Verical reflection points: ", vertical)
+		}
+
+		for _, row := range horizontal {
+			summary += 1 * (row + 1)
+		}
+
+		for _, column := range vertical {
+			summary += 100 * (column + 1)
+		}
+	}
+
+	run.Answer("Summary", summary)
 }
@@ -1,14 +1,15 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
 	"log"
-	"os"
 	"slices"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var solving = aoc.Trace(aoc.Solve)
+
 func horizontal_and_vertical_not_equal(horizontal []int, vertical []int, new_grid []string) bool {
 	new_vertical := find_vertical_reflection_points(new_grid)
 	new_horizontal := find_horizontal_reflection_points(new_grid)
@@ -21,14 +22,14 @@ func horizontal_and_vertical_not_equal(horizontal []int, vertical []int, new_gri
 
 	for _, row := range new_horizontal {
 		if !slices.Contains(horizontal, row) {
-			fmt.Println("New horizontal reflection")
+			solving.Debug("New horizontal reflection")
 			new_line = true
 		}
 	}
 
 	for _, column := range new_vertical {
 		if !slices.Contains(vertical, column) {
-			fmt.Println("New vertical reflection")
+			solving.Debug("New vertical reflection")
 			new_line = true
 		}
 	}
@@ -63,7 +64,7 @@ func try_all_replacements(grid []string) ([]int, []int) {
 	number_of_characters := len(grid) * len(grid[0])
 
 	for !horizontal_and_vertical_not_equal(horizontal, vertical, new_grid) {
-		fmt.Println("Changing character", character_number)
+		solving.Debug("Changing character", character_number)
 		// Flip that character from "." to "#" or vice versa.
 		// First: undo what we did before.
 		if character_number != 0 {
@@ -172,51 +173,67 @@ func find_horizontal_reflection_points(grid []string) []int {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(13, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
+	grids := make([][]string, 0)
 	grid := make([]string, 0)
 
 	summary := 0
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
 		if text == "" {
-			// Process grid and move on.
+			// End of a grid.
 
 			if len(grid) < 2 {
 				grid = make([]string, 0)
 				continue
 			}
 
-			horizontal, vertical := try_all_replacements(grid)
-
-			if DEBUG {
-				fmt.Println("Grid:")
-				for i, line := range grid {
-					fmt.Println(line, i)
-				}
-				fmt.Println("Horizontal reflection points: ", horizontal)
-				fmt.Println("Verical reflection points: ", vertical)
-			}
-
-			for _, row := range horizontal {
-				summary += 1 * (row + 1)
-			}
-
-			for _, column := range vertical {
-				summary += 100 * (column + 1)
-			}
+			grids = append(grids, grid)
 
 			// Reset grid
 			grid = make([]string, 0)
 		} else {
 			// Add to grid.
+			width := -1
+			if len(grid) > 0 {
+				width = len(grid[0])
+			}
+			aoc.Check(input.Wrap(aoc.GridRow(text, ".#", width)))
+
 			grid = append(grid, text)
 		}
 	}
 
-	fmt.Println("Summary:", summary)
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	for _, grid := range grids {
+		horizontal, vertical := try_all_replacements(grid)
+
+		if solving.Enabled(aoc.Debug) {
+			solving.Debug("Grid:")
+			for i, line := range grid {
+				solving.Debug(line, i)
+			}
+			solving.Debug("Horizontal reflection points: ", horizontal)
+			solving.Debug("Verical reflection points: ", vertical)
+		}
+
+		for _, row := range horizontal {
+			summary += 1 * (row + 1)
+		}
+
+		for _, column := range vertical {
+			summary += 100 * (column + 1)
+		}
+	}
+
+	run.Answer("Summary", summary)
 }
@@ -1,12 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var solving = aoc.Trace(aoc.Solve)
+var rendering = aoc.Trace(aoc.Render)
+
 var mapping = map[string]int{
 	".": 0,
 	"#": 1,
@@ -33,24 +37,24 @@ func parse_grid(lines []string) [][]int {
 	return grid
 }
 
-func vis_grid(grid [][]int) {
-	fmt.Print("┏")
+func vis_grid(output io.Writer, grid [][]int) {
+	fmt.Fprint(output, "┏")
 	for i := 0; i < len(grid[0]); i++ {
-		fmt.Print("━")
+		fmt.Fprint(output, "━")
 	}
-	fmt.Println("┓")
+	fmt.Fprintln(output, "┓")
 	for _, line := range grid {
-		fmt.Print("┃")
+		fmt.Fprint(output, "┃")
 		for _, char := range line {
-			fmt.Print(vis_mapping[char])
+			fmt.Fprint(output, vis_mapping[char])
 		}
-		fmt.Println("┃")
+		fmt.Fprintln(output, "┃")
 	}
-	fmt.Print("┗")
+	fmt.Fprint(output, "┗")
 	for i := 0; i < len(grid[0]); i++ {
-		fmt.Print("━")
+		fmt.Fprint(output, "━")
 	}
-	fmt.Println("┛")
+	fmt.Fprintln(output, "┛")
 }
 
 func propagate_all_balls_north(grid [][]int) [][]int {
@@ -100,46 +104,65 @@ func score_grid(grid [][]int) int {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(14, 1, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
+	grids := make([][]string, 0)
 	grid := make([]string, 0)
 
 	total_score := 0
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
 		if text == "" {
-			// Process grid and move on.
+			// End of a grid.
 
 			if len(grid) < 2 {
 				grid = make([]string, 0)
 				continue
 			}
 
-			parsed_grid := parse_grid(grid)
-			propagated_grid := propagate_all_balls_north(parsed_grid)
-			grid_score := score_grid(propagated_grid)
-
-			if DEBUG {
-				fmt.Println("Original Grid:")
-				vis_grid(parsed_grid)
-				fmt.Println("Propagated Grid:")
-				vis_grid(propagated_grid)
-				fmt.Println("Score: ", grid_score)
-			}
-
-			total_score += grid_score
+			grids = append(grids, grid)
 
 			// Reset grid
 			grid = make([]string, 0)
 		} else {
 			// Add to grid.
+			width := -1
+			if len(grid) > 0 {
+				width = len(grid[0])
+			}
+			aoc.Check(input.Wrap(aoc.GridRow(text, ".#O", width)))
+
 			grid = append(grid, text)
 		}
 	}
 
-	fmt.Println("Total score: ", total_score)
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	for _, grid := range grids {
+		parsed_grid := parse_grid(grid)
+		propagated_grid := propagate_all_balls_north(parsed_grid)
+		grid_score := score_grid(propagated_grid)
+
+		if rendering.Enabled(aoc.Debug) {
+			done := run.Time(aoc.Render)
+			rendering.Debug("Original Grid:")
+			vis_grid(rendering.Writer(aoc.Debug), parsed_grid)
+			rendering.Debug("Propagated Grid:")
+			vis_grid(rendering.Writer(aoc.Debug), propagated_grid)
+			done()
+		}
+
+		solving.Debug("Score: ", grid_score)
+
+		total_score += grid_score
+	}
+
+	run.Answer("Total score", total_score)
 }
@@ -1,12 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var solving = aoc.Trace(aoc.Solve)
+var rendering = aoc.Trace(aoc.Render)
+
 var mapping = map[string]int{
 	".": 0,
 	"#": 1,
@@ -33,24 +37,24 @@ func parse_grid(lines []string) [][]int {
 	return grid
 }
 
-func vis_grid(grid [][]int) {
-	fmt.Print("┏")
+func vis_grid(output io.Writer, grid [][]int) {
+	fmt.Fprint(output, "┏")
 	for i := 0; i < len(grid[0]); i++ {
-		fmt.Print("━")
+		fmt.Fprint(output, "━")
 	}
-	fmt.Println("┓")
+	fmt.Fprintln(output, "┓")
 	for _, line := range grid {
-		fmt.Print("┃")
+		fmt.Fprint(output, "┃")
 		for _, char := range line {
-			fmt.Print(vis_mapping[char])
+			fmt.Fprint(output, vis_mapping[char])
 		}
-		fmt.Println("┃")
+		fmt.Fprintln(output, "┃")
 	}
-	fmt.Print("┗")
+	fmt.Fprint(output, "┗")
 	for i := 0; i < len(grid[0]); i++ {
-		fmt.Print("━")
+		fmt.Fprint(output, "━")
 	}
-	fmt.Println("┛")
+	fmt.Fprintln(output, "┛")
 }
 
 func propagate_all_balls_north(grid [][]int) [][]int {
@@ -189,114 +193,130 @@ func score_grid(grid [][]int) int {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(14, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
+	grids := make([][]string, 0)
 	grid := make([]string, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
 		if text == "" {
-			// Process grid and move on.
+			// End of a grid.
 
 			if len(grid) < 2 {
 				grid = make([]string, 0)
 				continue
 			}
 
-			parsed_grid := parse_grid(grid)
-			propagated_grid := parsed_grid
+			grids = append(grids, grid)
 
-			if DEBUG {
-				fmt.Println("Original Grid:")
-				vis_grid(parsed_grid)
+			// Reset grid
+			grid = make([]string, 0)
+		} else {
+			// Add to grid.
+			width := -1
+			if len(grid) > 0 {
+				width = len(grid[0])
 			}
+			aoc.Check(input.Wrap(aoc.GridRow(text, ".#O", width)))
+
+			grid = append(grid, text)
+		}
+	}
 
-			n_iters := 1000
+	aoc.Check(input.Err())
+	run.Parsed()
 
-			scores := make([]int, n_iters)
+	for _, grid := range grids {
+		parsed_grid := parse_grid(grid)
+		propagated_grid := parsed_grid
 
-			for i := 0; i < n_iters; i++ {
-				propagated_grid = propagate_all_balls_north(propagated_grid)
-				propagated_grid = propagate_all_balls_west(propagated_grid)
-				propagated_grid = propagate_all_balls_south(propagated_grid)
-				propagated_grid = propagate_all_balls_east(propagated_grid)
+		if rendering.Enabled(aoc.Debug) {
+			done := run.Time(aoc.Render)
+			rendering.Debug("Original Grid:")
+			vis_grid(rendering.Writer(aoc.Debug), parsed_grid)
+			done()
+		}
 
-				grid_score := score_grid(propagated_grid)
+		n_iters := 1000
 
-				if DEBUG {
-					fmt.Println("Iteration: ", i+1)
-					fmt.Println("Score: ", grid_score)
-					// vis_grid(propagated_grid)
-				}
+		scores := make([]int, n_iters)
 
-				scores[i] = grid_score
-			}
+		for i := 0; i < n_iters; i++ {
+			propagated_grid = propagate_all_balls_north(propagated_grid)
+			propagated_grid = propagate_all_balls_west(propagated_grid)
+			propagated_grid = propagate_all_balls_south(propagated_grid)
+			propagated_grid = propagate_all_balls_east(propagated_grid)
 
-			// Now need to find the periodicity of the sequence.
-			up_to_now := make([]int, 0)
+			grid_score := score_grid(propagated_grid)
 
-			period := 0
+			solving.Debug("Iteration: ", i+1)
+			solving.Debug("Score: ", grid_score)
+			// vis_grid(rendering.Writer(aoc.Debug), propagated_grid)
 
-			for i := n_iters - 1; i > 0; i-- {
-				up_to_now = append(up_to_now, scores[i])
+			scores[i] = grid_score
+		}
 
-				// Go from i through up to now and see if it repeats.
-				// If it does, then we have found the period.
+		// Now need to find the periodicity of the sequence.
+		up_to_now := make([]int, 0)
 
-				if len(up_to_now) < 2 {
-					continue
-				}
+		period := 0
 
-				// Double up up to now... (caveman style)
+		for i := n_iters - 1; i > 0; i-- {
+			up_to_now = append(up_to_now, scores[i])
 
-				double_up_to_now := make([]int, 0)
-				for _, val := range up_to_now {
-					double_up_to_now = append(double_up_to_now, val)
-				}
-				for _, val := range up_to_now {
-					double_up_to_now = append(double_up_to_now, val)
-				}
+			// Go from i through up to now and see if it repeats.
+			// If it does, then we have found the period.
 
-				// fmt.Println("Double up to now: ", double_up_to_now)
+			if len(up_to_now) < 2 {
+				continue
+			}
 
-				repeats := true
+			// Double up up to now... (caveman style)
 
-				for j := 0; j < len(double_up_to_now)-1; j++ {
-					// fmt.Println("Comparinig: ", scores[n_iters-j-1], double_up_to_now[j])
-					if scores[n_iters-j-1] != double_up_to_now[j] {
-						repeats = false
-						break
-					}
-				}
+			double_up_to_now := make([]int, 0)
+			for _, val := range up_to_now {
+				double_up_to_now = append(double_up_to_now, val)
+			}
+			for _, val := range up_to_now {
+				double_up_to_now = append(double_up_to_now, val)
+			}
+
+			// fmt.Println("Double up to now: ", double_up_to_now)
+
+			repeats := true
 
-				if !repeats {
-					continue
-				} else {
-					period = len(up_to_now)
-					fmt.Println("Found period: ", period)
-					fmt.Println("Periodic sequence: ", up_to_now)
+			for j := 0; j < len(double_up_to_now)-1; j++ {
+				// fmt.Println("Comparinig: ", scores[n_iters-j-1], double_up_to_now[j])
+				if scores[n_iters-j-1] != double_up_to_now[j] {
+					repeats = false
 					break
 				}
 			}
 
-			n_cycles := 1000000000
+			if !repeats {
+				continue
+			} else {
+				period = len(up_to_now)
+				solving.Info("Found period: ", period)
+				solving.Info("Periodic sequence: ", up_to_now)
+				break
+			}
+		}
 
-			// Now we can calculate the score at the end.
-			n_cycles_maps_to := (n_cycles - n_iters) % period
-			fmt.Println("n_cycles_maps_to: ", n_cycles_maps_to)
-			// Into the sequence
-			final_score := scores[n_iters-period+n_cycles_maps_to-1]
+		n_cycles := 1000000000
 
-			fmt.Println("Final Score: ", final_score)
+		// Now we can calculate the score at the end.
+		n_cycles_maps_to := (n_cycles - n_iters) % period
+		solving.Info("n_cycles_maps_to: ", n_cycles_maps_to)
+		// Into the sequence
+		final_score := scores[n_iters-period+n_cycles_maps_to-1]
 
-			// Reset grid
-			grid = make([]string, 0)
-		} else {
-			// Add to grid.
-			grid = append(grid, text)
-		}
+		run.Answer("Final Score", final_score)
 	}
 }
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
-	"os"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+
 type Token struct {
 	base string
 	hash uint8
@@ -38,33 +39,34 @@ func parse_line(str string) []Token {
 }
 
 func main() {
-	DEBUG := true
+	aoc.Main(15, 1, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	tokens := make([]Token, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
-		if DEBUG {
-			fmt.Println("Input: ", text)
-		}
+		parsing.Debug("Input: ", text)
 
 		new_tokens := parse_line(text)
 
-		if DEBUG {
-			fmt.Println("Tokens: ", new_tokens)
-		}
+		parsing.Debug("Tokens: ", new_tokens)
 
 		tokens = append(tokens, new_tokens...)
 	}
 
+	aoc.Check(input.Err())
+	run.Parsed()
+
 	total := 0
 
 	for _, token := range tokens {
 		total += int(token.hash)
 	}
 
-	fmt.Println("Total score: ", total)
+	run.Answer("Total score", total)
 }
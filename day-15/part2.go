@@ -1,14 +1,22 @@
 package main
 
 import (
-	"bufio"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+
 const (
 	REMOVE  = "-"
 	REPLACE = "="
@@ -22,6 +30,27 @@ type Token struct {
 	focal_length int
 }
 
+// A single lens as it sits in a box, as recorded in the trace.
+type Lens struct {
+	Label       string `json:"label"`
+	FocalLength int    `json:"focal_length"`
+}
+
+// One line of the trace log: the effect of a single `=` or `-` step. Slots
+// are -1 when the lens is not in the box, and Displaced holds the lens that
+// was replaced (`=`) or removed (`-`), if any.
+type TraceEvent struct {
+	Step        int    `json:"step"`
+	Token       string `json:"token"`
+	Instruction string `json:"instruction"`
+	Label       string `json:"label"`
+	Box         int    `json:"box"`
+	FocalLength int    `json:"focal_length,omitempty"`
+	SlotBefore  int    `json:"slot_before"`
+	SlotAfter   int    `json:"slot_after"`
+	Displaced   *Lens  `json:"displaced,omitempty"`
+}
+
 func calculate_hash(str string) uint8 {
 	hash := uint8(0)
 
@@ -35,14 +64,17 @@ func calculate_hash(str string) uint8 {
 	return hash
 }
 
-func parse_line(str string) []Token {
+func parse_line(str string) ([]Token, error) {
 	tokens := []Token{}
 
-	split := strings.Split(str, ",")
+	column := 1
 
-	for _, char := range split {
+	for _, char := range strings.Split(str, ",") {
 		if strings.Contains(char, REMOVE) {
-			instruction := strings.Split(char, REMOVE)[0]
+			instruction, rest, _ := strings.Cut(char, REMOVE)
+			if instruction == "" || rest != "" {
+				return nil, aoc.Expected(column, "\"<label>-\"", aoc.Found(char))
+			}
 			tokens = append(tokens, Token{
 				base:         char,
 				hash:         calculate_hash(instruction),
@@ -51,30 +83,45 @@ func parse_line(str string) []Token {
 				instruction:  REMOVE,
 			})
 		} else if strings.Contains(char, REPLACE) {
-			token_split := strings.Split(char, REPLACE)
-			focal_length, _ := strconv.Atoi(token_split[1])
+			label, length, _ := strings.Cut(char, REPLACE)
+			if label == "" {
+				return nil, aoc.Expected(column, "\"<label>=<focal length>\"", aoc.Found(char))
+			}
+			focal_length, err := strconv.Atoi(length)
+			if err != nil || focal_length < 1 || focal_length > 9 {
+				return nil, aoc.Expected(column+len(label)+1, "a focal length from 1 to 9", aoc.Found(length))
+			}
 			tokens = append(tokens, Token{
 				base:         char,
-				hash:         calculate_hash(token_split[0]),
-				label:        token_split[0],
+				hash:         calculate_hash(label),
+				label:        label,
 				focal_length: focal_length,
 				instruction:  REPLACE,
 			})
 		} else {
-			log.Fatal("Invalid token: ", char)
+			return nil, aoc.Expected(column, "\"<label>-\" or \"<label>=<focal length>\"", aoc.Found(char))
 		}
+
+		column += len(char) + 1
 	}
 
-	return tokens
+	return tokens, nil
 }
 
-func print_box(box map[uint8][]Token) {
-	for hash, tokens := range box {
-		fmt.Print(hash, ": [")
-		for _, token := range tokens {
-			fmt.Print(token.base, " ")
+func print_box(output io.Writer, box map[uint8][]Token) {
+	// Go map iteration order is random, so sort the box ids first.
+	hashes := make([]int, 0, len(box))
+	for hash := range box {
+		hashes = append(hashes, int(hash))
+	}
+	sort.Ints(hashes)
+
+	for _, hash := range hashes {
+		fmt.Fprint(output, hash, ": [")
+		for _, token := range box[uint8(hash)] {
+			fmt.Fprint(output, token.base, " ")
 		}
-		fmt.Println("]")
+		fmt.Fprintln(output, "]")
 	}
 }
 
@@ -94,11 +141,39 @@ func focusing_power(tokens []Token) int {
 	return total_focusing_power
 }
 
-func tokens_to_box(tokens []Token) map[uint8][]Token {
+func find_slot(container []Token, label string) int {
+	for i, box_token := range container {
+		if box_token.label == label {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Applies every token in turn. If trace is not nil, each step's effect is
+// written to it as one JSON object per line.
+func tokens_to_box(tokens []Token, trace *json.Encoder) map[uint8][]Token {
 	box := make(map[uint8][]Token)
 
-	for _, token := range tokens {
+	for step, token := range tokens {
+		event := TraceEvent{
+			Step:        step,
+			Token:       token.base,
+			Instruction: token.instruction,
+			Label:       token.label,
+			Box:         int(token.hash),
+			SlotBefore:  find_slot(box[token.hash], token.label),
+		}
+
+		if event.SlotBefore >= 0 {
+			displaced := box[token.hash][event.SlotBefore]
+			event.Displaced = &Lens{displaced.label, displaced.focal_length}
+		}
+
 		if token.instruction == REPLACE {
+			event.FocalLength = token.focal_length
+
 			// Current box state
 			container := box[token.hash]
 
@@ -135,49 +210,73 @@ func tokens_to_box(tokens []Token) map[uint8][]Token {
 		} else {
 			log.Fatal("Invalid instruction: ", token.instruction)
 		}
-		fmt.Println("Current token:", token.base)
-		fmt.Println("Current box:")
-		print_box(box)
 
+		if trace != nil {
+			event.SlotAfter = find_slot(box[token.hash], token.label)
+
+			if err := trace.Encode(event); err != nil {
+				log.Fatal("Could not write trace: ", err)
+			}
+		}
+
+		if solving.Enabled(aoc.Debug) {
+			solving.Debug("Current token:", token.base)
+			solving.Debug("Current box:")
+			print_box(solving.Writer(aoc.Debug), box)
+		}
 	}
 
 	return box
 }
 
+var trace_path = flag.String("step-log", "", "write a JSON Lines trace of every step to this file")
+
 func main() {
-	DEBUG := true
+	aoc.Main(15, 2, solve)
+}
 
-	scanner := bufio.NewScanner(os.Stdin)
+func solve(run *aoc.Run) {
+	input := run.Input
 
 	tokens := make([]Token, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
-		if DEBUG {
-			fmt.Println("Input: ", text)
-		}
+		parsing.Debug("Input: ", text)
 
-		new_tokens := parse_line(text)
+		new_tokens, err := parse_line(text)
+		aoc.Check(input.Wrap(err))
 
-		if DEBUG {
-			fmt.Println("Tokens: ", new_tokens)
-		}
+		parsing.Debug("Tokens: ", new_tokens)
 
 		tokens = append(tokens, new_tokens...)
 	}
 
-	box := tokens_to_box(tokens)
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	var trace *json.Encoder
 
-	if DEBUG {
-		fmt.Println("Box: ", box)
+	if *trace_path != "" {
+		trace_file, err := os.Create(*trace_path)
+		if err != nil {
+			log.Fatal("Could not create trace file: ", err)
+		}
+		defer trace_file.Close()
+
+		trace = json.NewEncoder(trace_file)
 	}
 
+	box := tokens_to_box(tokens, trace)
+
+	solving.Debug("Box: ", box)
+
 	total_power := 0
 
 	for _, tokens := range box {
 		total_power += focusing_power(tokens)
 	}
 
-	fmt.Println("Total focusing power:", total_power)
+	run.Answer("Total focusing power", total_power)
 }
@@ -0,0 +1,208 @@
+package main
+
+// Rebuilds the lens boxes from a trace written by `part2.go -step-log`, so
+// that the state can be inspected at any step without re-running the
+// solver.
+//
+//	go run replay.go -step-log trace.jsonl -step 3
+//	go run replay.go -step-log trace.jsonl -step 3 -diff 7
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+
+	"advent-of-code/aoc"
+)
+
+type Lens struct {
+	Label       string `json:"label"`
+	FocalLength int    `json:"focal_length"`
+}
+
+type TraceEvent struct {
+	Step        int    `json:"step"`
+	Token       string `json:"token"`
+	Instruction string `json:"instruction"`
+	Label       string `json:"label"`
+	Box         int    `json:"box"`
+	FocalLength int    `json:"focal_length,omitempty"`
+	SlotBefore  int    `json:"slot_before"`
+	SlotAfter   int    `json:"slot_after"`
+	Displaced   *Lens  `json:"displaced,omitempty"`
+}
+
+func read_trace(path string) ([]TraceEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := make([]TraceEvent, 0)
+
+	input := aoc.NewInput(file, path)
+
+	for input.Scan() {
+		event := TraceEvent{}
+
+		if err := json.Unmarshal([]byte(input.Text()), &event); err != nil {
+			return nil, input.At(0, "a JSON trace event", err.Error())
+		}
+
+		if event.Step != len(events) {
+			return nil, input.At(0, fmt.Sprintf("step %d", len(events)), fmt.Sprintf("step %d", event.Step))
+		}
+
+		events = append(events, event)
+	}
+
+	return events, input.Err()
+}
+
+// Applies events up to and including step to an empty set of boxes.
+func rebuild(events []TraceEvent, step int) map[int][]Lens {
+	boxes := make(map[int][]Lens)
+
+	for _, event := range events[:step+1] {
+		container := boxes[event.Box]
+
+		switch event.Instruction {
+		case "=":
+			lens := Lens{event.Label, event.FocalLength}
+
+			if event.SlotBefore < 0 {
+				boxes[event.Box] = append(container, lens)
+			} else {
+				container[event.SlotBefore] = lens
+			}
+		case "-":
+			if event.SlotBefore >= 0 {
+				boxes[event.Box] = slices.Delete(slices.Clone(container), event.SlotBefore, event.SlotBefore+1)
+			}
+		default:
+			log.Fatal("Invalid instruction in trace: ", event.Instruction)
+		}
+	}
+
+	return boxes
+}
+
+func box_power(box int, lenses []Lens) int {
+	power := 0
+
+	for i, lens := range lenses {
+		power += (box + 1) * (i + 1) * lens.FocalLength
+	}
+
+	return power
+}
+
+func sorted_boxes(boxes map[int][]Lens) []int {
+	ids := make([]int, 0, len(boxes))
+
+	for id, lenses := range boxes {
+		if len(lenses) > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+func format_box(lenses []Lens) string {
+	str := "["
+
+	for i, lens := range lenses {
+		if i > 0 {
+			str += " "
+		}
+		str += fmt.Sprintf("%s %d", lens.Label, lens.FocalLength)
+	}
+
+	return str + "]"
+}
+
+func print_state(boxes map[int][]Lens) {
+	total_power := 0
+
+	for _, id := range sorted_boxes(boxes) {
+		power := box_power(id, boxes[id])
+		total_power += power
+
+		fmt.Printf("Box %d: %s (power %d)\n", id, format_box(boxes[id]), power)
+	}
+
+	fmt.Println("Total focusing power:", total_power)
+}
+
+func print_diff(events []TraceEvent, from int, to int) {
+	before := rebuild(events, from)
+	after := rebuild(events, to)
+
+	ids := sorted_boxes(before)
+	for _, id := range sorted_boxes(after) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	total_delta := 0
+
+	for _, id := range ids {
+		if slices.Equal(before[id], after[id]) {
+			continue
+		}
+
+		delta := box_power(id, after[id]) - box_power(id, before[id])
+		total_delta += delta
+
+		fmt.Printf("Box %d: %s -> %s (power %+d)\n", id, format_box(before[id]), format_box(after[id]), delta)
+	}
+
+	fmt.Println("Steps in between:")
+	lo, hi := min(from, to), max(from, to)
+	for _, event := range events[lo+1 : hi+1] {
+		fmt.Printf("  %d: %s (box %d, slot %d -> %d)\n", event.Step, event.Token, event.Box, event.SlotBefore, event.SlotAfter)
+	}
+
+	fmt.Println("Focusing power change:", total_delta)
+}
+
+func main() {
+	trace_path := flag.String("step-log", "trace.jsonl", "trace written by part2.go -step-log")
+	step := flag.Int("step", -1, "step to rebuild the boxes at (default: the last step)")
+	diff := flag.Int("diff", -1, "if set, show what changed between -step and this step")
+	flag.Parse()
+
+	events, err := read_trace(*trace_path)
+	aoc.Check(err)
+
+	if len(events) == 0 {
+		log.Fatal("Trace is empty")
+	}
+
+	if *step < 0 {
+		*step = len(events) - 1
+	}
+
+	for _, s := range []int{*step, *diff} {
+		if s >= len(events) {
+			log.Fatal("Step ", s, " is past the end of the trace (", len(events), " steps)")
+		}
+	}
+
+	if *diff >= 0 {
+		print_diff(events, *step, *diff)
+	} else {
+		fmt.Println("After step", *step, "("+events[*step].Token+"):")
+		print_state(rebuild(events, *step))
+	}
+}
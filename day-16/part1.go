@@ -1,13 +1,19 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
-const DEBUG = false
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+var rendering = aoc.Trace(aoc.Render)
+
+// Checked once per run rather than at every step follow_path takes.
+var trace_steps bool
 
 var color_red = "\033[31m"
 var color_none = "\033[0m"
@@ -216,25 +222,25 @@ func rows_to_nodes(rows []string) [][]Node {
 	return nodes
 }
 
-func print_node_array(nodes [][]Node) {
+func print_node_array(output io.Writer, nodes [][]Node) {
 	for _, row := range nodes {
 		for _, node := range row {
 			if node.visited > 0 && node.visited < 100 {
-				fmt.Print(color_red)
+				fmt.Fprint(output, color_red)
 			}
 			if node.visited > 100 {
-				fmt.Print(color_green)
+				fmt.Fprint(output, color_green)
 			}
 			if node.visited > 0 && node.symbol == "." {
-				fmt.Print("#")
+				fmt.Fprint(output, "#")
 			} else {
-				fmt.Print(node.render)
+				fmt.Fprint(output, node.render)
 			}
 			if node.visited > 0 {
-				fmt.Print(color_none)
+				fmt.Fprint(output, color_none)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(output)
 	}
 }
 
@@ -260,16 +266,16 @@ func follow_path(nodes [][]Node, position []int, direction []int, path_history [
 	y := next_position[1]
 
 	if x < 0 || x >= len(nodes[0]) || y < 0 || y >= len(nodes) {
-		if DEBUG {
-			fmt.Println("Terminating at: ", next_position)
+		if trace_steps {
+			solving.Debug("Terminating at: ", next_position)
 		}
 		return
 	}
 
 	// Base case: we are caught in a trap!
 	if contains_loop(path_history, hash_position(position, direction)) {
-		if DEBUG {
-			fmt.Println("Terminating at: ", next_position)
+		if trace_steps {
+			solving.Debug("Terminating at: ", next_position)
 		}
 		return
 	}
@@ -278,9 +284,9 @@ func follow_path(nodes [][]Node, position []int, direction []int, path_history [
 		return
 	}
 
-	if DEBUG {
-		fmt.Println("Visiting: ", next_position, "with symbol", nodes[y][x].symbol)
-		fmt.Println("Should terminate at: ", len(nodes[0]), len(nodes))
+	if trace_steps {
+		solving.Debug("Visiting: ", next_position, "with symbol", nodes[y][x].symbol)
+		solving.Debug("Should terminate at: ", len(nodes[0]), len(nodes))
 	}
 
 	next := nodes[y][x]
@@ -316,20 +322,37 @@ func count_energized(nodes [][]Node) int {
 }
 
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
+	aoc.Main(16, 1, solve)
+}
+
+func solve(run *aoc.Run) {
+	input := run.Input
+
+	trace_steps = solving.Enabled(aoc.Debug)
 
 	all_rows := make([]string, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
+		parsing.Debug("Given: ", text)
+
+		width := -1
+		if len(all_rows) > 0 {
+			width = len(all_rows[0])
 		}
+		aoc.Check(input.Wrap(aoc.GridRow(text, `|-/\.`, width)))
 
 		all_rows = append(all_rows, text)
 	}
 
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	if len(all_rows) == 0 {
+		aoc.Check(input.Missing("a grid of mirrors"))
+	}
+
 	nodes := rows_to_nodes(all_rows)
 
 	// We visited our first position!
@@ -337,7 +360,11 @@ func main() {
 
 	follow_path(nodes, []int{-1, 0}, []int{1, 0}, make([]string, 0))
 
-	print_node_array(nodes)
+	if rendering.Enabled(aoc.Debug) {
+		done := run.Time(aoc.Render)
+		print_node_array(rendering.Writer(aoc.Debug), nodes)
+		done()
+	}
 
-	fmt.Println("Energized nodes: ", count_energized(nodes))
+	run.Answer("Energized nodes", count_energized(nodes))
 }
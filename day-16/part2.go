@@ -1,14 +1,20 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"io"
 	"slices"
 	"strings"
+
+	"advent-of-code/aoc"
 )
 
-const DEBUG = false
+var parsing = aoc.Trace(aoc.Parse)
+var solving = aoc.Trace(aoc.Solve)
+var rendering = aoc.Trace(aoc.Render)
+
+// Checked once per run rather than at every step follow_path takes.
+var trace_steps bool
 
 const PART2 = true
 
@@ -219,25 +225,25 @@ func rows_to_nodes(rows []string) [][]Node {
 	return nodes
 }
 
-func print_node_array(nodes [][]Node) {
+func print_node_array(output io.Writer, nodes [][]Node) {
 	for _, row := range nodes {
 		for _, node := range row {
 			if node.visited > 0 && node.visited < 100 {
-				fmt.Print(color_red)
+				fmt.Fprint(output, color_red)
 			}
 			if node.visited > 100 {
-				fmt.Print(color_green)
+				fmt.Fprint(output, color_green)
 			}
 			if node.visited > 0 && node.symbol == "." {
-				fmt.Print("#")
+				fmt.Fprint(output, "#")
 			} else {
-				fmt.Print(node.render)
+				fmt.Fprint(output, node.render)
 			}
 			if node.visited > 0 {
-				fmt.Print(color_none)
+				fmt.Fprint(output, color_none)
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(output)
 	}
 }
 
@@ -254,8 +260,8 @@ func follow_path(nodes [][]Node, position []int, direction []int) {
 	y := next_position[1]
 
 	if x < 0 || x >= len(nodes[0]) || y < 0 || y >= len(nodes) {
-		if DEBUG {
-			fmt.Println("Terminating at: ", next_position)
+		if trace_steps {
+			solving.Debug("Terminating at: ", next_position)
 		}
 		return
 	}
@@ -266,9 +272,9 @@ func follow_path(nodes [][]Node, position []int, direction []int) {
 		return
 	}
 
-	if DEBUG {
-		fmt.Println("Visiting: ", next_position, "with symbol", nodes[y][x].symbol)
-		fmt.Println("Should terminate at: ", len(nodes[0]), len(nodes))
+	if trace_steps {
+		solving.Debug("Visiting: ", next_position, "with symbol", nodes[y][x].symbol)
+		solving.Debug("Should terminate at: ", len(nodes[0]), len(nodes))
 	}
 
 	for _, connection := range next.connections {
@@ -302,20 +308,37 @@ func count_energized(nodes [][]Node) int {
 }
 
 func main() {
-	scanner := bufio.NewScanner(os.Stdin)
+	aoc.Main(16, 2, solve)
+}
+
+func solve(run *aoc.Run) {
+	input := run.Input
+
+	trace_steps = solving.Enabled(aoc.Debug)
 
 	all_rows := make([]string, 0)
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+
+		parsing.Debug("Given: ", text)
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
+		width := -1
+		if len(all_rows) > 0 {
+			width = len(all_rows[0])
 		}
+		aoc.Check(input.Wrap(aoc.GridRow(text, `|-/\.`, width)))
 
 		all_rows = append(all_rows, text)
 	}
 
+	aoc.Check(input.Err())
+	run.Parsed()
+
+	if len(all_rows) == 0 {
+		aoc.Check(input.Missing("a grid of mirrors"))
+	}
+
 	if !PART2 {
 		nodes := rows_to_nodes(all_rows)
 
@@ -324,22 +347,33 @@ func main() {
 
 		follow_path(nodes, []int{-1, 0}, []int{1, 0})
 
-		print_node_array(nodes)
+		if rendering.Enabled(aoc.Debug) {
+			done := run.Time(aoc.Render)
+			print_node_array(rendering.Writer(aoc.Debug), nodes)
+			done()
+		}
 
-		fmt.Println("Energized nodes: ", count_energized(nodes))
+		run.Answer("Energized nodes", count_energized(nodes))
 	}
 
 	if PART2 {
 		maximal_energized_nodes := 0
 		best_starting_location := []int{0, 0}
 
+		ctx := run.Context()
+
+		// From both ends of every row and every column.
+		progress := run.Progress("Launching", 2*len(all_rows)+2*len(all_rows[0]))
+
 		for i := 0; i < len(all_rows); i++ {
+			if ctx.Err() != nil {
+				break
+			}
+
 			// Launch from every possible point.
 			launch_point := []int{-1, i}
 			launch_direction := []int{1, 0}
 
-			fmt.Println("Launching horizontally: ", i)
-
 			// Reset the grid
 			nodes := rows_to_nodes(all_rows)
 			nodes[i][0].visited++
@@ -356,8 +390,6 @@ func main() {
 			launch_point = []int{len(all_rows[0]), i}
 			launch_direction = []int{-1, 0}
 
-			fmt.Println("Launching horizontally: ", -i)
-
 			// Reset the grid
 			nodes = rows_to_nodes(all_rows)
 			nodes[i][len(all_rows[0])-1].visited++
@@ -369,16 +401,20 @@ func main() {
 				maximal_energized_nodes = number_energized
 				best_starting_location = launch_point
 			}
+
+			progress.Add(2)
 		}
 
 		// Now vertically
 		for i := 0; i < len(all_rows[0]); i++ {
+			if ctx.Err() != nil {
+				break
+			}
+
 			// Launch from every possible point.
 			launch_point := []int{i, -1}
 			launch_direction := []int{0, 1}
 
-			fmt.Println("Launching vertically: ", i)
-
 			// Reset the grid
 			nodes := rows_to_nodes(all_rows)
 			nodes[0][i].visited++
@@ -395,8 +431,6 @@ func main() {
 			launch_point = []int{i, len(all_rows)}
 			launch_direction = []int{0, -1}
 
-			fmt.Println("Launching vertically: ", -i)
-
 			// Reset the grid
 			nodes = rows_to_nodes(all_rows)
 			nodes[len(all_rows)-1][i].visited++
@@ -408,9 +442,18 @@ func main() {
 				maximal_energized_nodes = number_energized
 				best_starting_location = launch_point
 			}
+
+			progress.Add(2)
+		}
+
+		progress.Done()
+
+		if ctx.Err() != nil {
+			solving.Info("Stopped launching: ", ctx.Err())
 		}
 
-		fmt.Println("Maximal energized nodes: ", maximal_energized_nodes, "from starting location:", best_starting_location)
+		solving.Info("Best starting location:", best_starting_location)
+		run.Answer("Maximal energized nodes", maximal_energized_nodes)
 	}
 
 }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func BenchmarkPart2(b *testing.B) {
+	// Only the example input is checked in for this day.
+	aoc.Benchmark(b, 16, 2, "test.txt", solve)
+}
@@ -1,171 +1,119 @@
 package main
 
 import (
-	"bufio"
-	"container/heap"
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"strconv"
 	"strings"
+	"time"
+
+	"advent-of-code/aoc"
+	"advent-of-code/search"
 )
 
-const DEBUG = true
+var parsing = aoc.Trace(aoc.Parse)
+var searching = aoc.Trace(aoc.Search)
+var rendering = aoc.Trace(aoc.Render)
+
 const color_red = "\033[31m"
 const color_green = "\033[32m"
 const color_yellow = "\033[33m"
 const color_none = "\033[0m"
 
-type Node struct {
-	cost        int
-	visited     bool
-	connections []string
-	position    []int
-	// Current heuristics
-	g               int
-	h               int
-	best_connection string
-	on_path         bool
+var directions = [][]int{
+	{1, 0},
+	{0, 1},
+	{-1, 0},
+	{0, -1},
+}
+
+// Where the crucible is, which way it is heading (an index into
+// directions, or -1 before it has moved) and how many blocks it has
+// travelled in that direction so far. Tracking the run in the state is
+// what enforces the turning rules.
+type Crucible struct {
+	x         int
+	y         int
+	direction int
+	run       int
 }
 
 type Grid struct {
-	nodes  map[string]Node
+	costs  [][]int
 	height int
 	width  int
+	// Blocks the crucible must move in a straight line before it may turn
+	// (or stop), and after which it must turn.
+	min_run int
+	max_run int
 }
 
-func node_hash(x, y int) string {
-	return fmt.Sprintf("%d,%d", x, y)
-}
-
-func connections_from_position(x int, y int, max_x int, max_y int) []string {
-	connections := make([]string, 0)
-
-	if x > 0 {
-		connections = append(connections, node_hash(x-1, y))
-	}
-
-	if x < max_x {
-		connections = append(connections, node_hash(x+1, y))
-	}
-
-	if y > 0 {
-		connections = append(connections, node_hash(x, y-1))
-	}
-
-	if y < max_y {
-		connections = append(connections, node_hash(x, y+1))
-	}
-
-	return connections
-}
-
-func rows_to_nodes(rows []string) Grid {
-	node_map := make(map[string]Node)
-
-	max_x := len(rows[0]) - 1
-	max_y := len(rows) - 1
+func rows_to_grid(rows []string, min_run int, max_run int) Grid {
+	costs := make([][]int, len(rows))
 
 	for y, row := range rows {
+		costs[y] = make([]int, len(row))
+
+		// The rows were checked to be all digits as they were read.
 		for x, char := range row {
-			// Get character value
-			value, _ := strconv.Atoi(string(char))
-
-			hash := node_hash(x, y)
-
-			node_map[hash] = Node{
-				cost:            value,
-				visited:         false,
-				connections:     connections_from_position(x, y, max_x, max_y),
-				position:        []int{x, y},
-				g:               0,
-				h:               0,
-				best_connection: "",
-			}
+			costs[y][x] = int(char - '0')
 		}
 	}
 
 	return Grid{
-		nodes:  node_map,
-		height: max_y + 1,
-		width:  max_x + 1,
+		costs:   costs,
+		height:  len(rows),
+		width:   len(rows[0]),
+		min_run: min_run,
+		max_run: max_run,
 	}
 }
 
-func visualize_grid(grid Grid) {
-	for y := 0; y < grid.height; y++ {
-		for x := 0; x < grid.width; x++ {
-			hash := node_hash(x, y)
+func (grid Grid) neighbours(crucible Crucible) []search.Edge[Crucible] {
+	edges := make([]search.Edge[Crucible], 0, 3)
 
-			node := grid.nodes[hash]
-
-			if node.on_path {
-				fmt.Print(color_yellow, node.cost, color_none)
-			} else if node.visited {
-				fmt.Print(color_red, node.cost, color_none)
-			} else {
-				fmt.Print(color_green, node.cost, color_none)
+	for direction, step := range directions {
+		if crucible.direction >= 0 {
+			if direction == (crucible.direction+2)%4 {
+				// No reversing.
+				continue
 			}
-		}
-
-		fmt.Println()
-	}
-}
-
-func visualize_g(grid Grid) {
-	for y := 0; y < grid.height; y++ {
-		for x := 0; x < grid.width; x++ {
-			hash := node_hash(x, y)
 
-			node := grid.nodes[hash]
+			if direction == crucible.direction && crucible.run >= grid.max_run {
+				continue
+			}
 
-			if node.visited {
-				fmt.Printf("%s%-4d%s", color_red, node.g, color_none)
-			} else {
-				fmt.Printf("%s%-4d%s", color_green, node.g, color_none)
+			if direction != crucible.direction && crucible.run < grid.min_run {
+				continue
 			}
 		}
 
-		fmt.Println()
-	}
-}
-
-func visualize_connections(grid Grid) {
-	for y := 0; y < grid.height; y++ {
-		for x := 0; x < grid.width; x++ {
-			hash := node_hash(x, y)
-
-			node := grid.nodes[hash]
-
-			if node.on_path {
-				fmt.Print(color_yellow)
-			}
+		x := crucible.x + step[0]
+		y := crucible.y + step[1]
 
-			if node.visited {
-				// Find connection
-				connected_node := grid.nodes[node.best_connection]
+		if x < 0 || y < 0 || x >= grid.width || y >= grid.height {
+			continue
+		}
 
-				connected_node_x := connected_node.position[0]
-				connected_node_y := connected_node.position[1]
+		run := 1
+		if direction == crucible.direction {
+			run = crucible.run + 1
+		}
 
-				if connected_node_x == x {
-					fmt.Print("|")
-				} else if connected_node_y == y {
-					fmt.Print("-")
-				} else {
-					log.Fatal("Something went wrong")
-				}
-			} else {
-				fmt.Print(" ")
-			}
+		edges = append(edges, search.Edge[Crucible]{
+			To:   Crucible{x, y, direction, run},
+			Cost: grid.costs[y][x],
+		})
+	}
 
-			if node.on_path {
-				fmt.Print(color_none)
-			}
+	return edges
+}
 
-		}
-		fmt.Println()
-	}
+func (grid Grid) is_goal(crucible Crucible) bool {
+	return crucible.x == grid.width-1 && crucible.y == grid.height-1 && crucible.run >= grid.min_run
 }
 
 func intAbs(x int) int {
@@ -175,325 +123,269 @@ func intAbs(x int) int {
 	return x
 }
 
-// Try with just manhattan distance at first?
-func distance_heuristic(node Node, end Node) int {
-	return intAbs(end.position[0]-node.position[0]) + intAbs(end.position[1]-node.position[1])
+// Every block costs at least 1, so the manhattan distance never
+// overestimates.
+func distance_heuristic(crucible Crucible, grid Grid) int {
+	return intAbs(grid.width-1-crucible.x) + intAbs(grid.height-1-crucible.y)
 }
 
-func weighted_manhattan_heuristic(node Node, end Node, grid Grid) int {
-	start_x := min(node.position[0], end.position[0])
-	start_y := min(node.position[1], end.position[1])
-	end_x := max(node.position[0], end.position[0])
-	end_y := max(node.position[1], end.position[1])
-
-	// L-path cost. This is wrong!
-	// total_cost := 0
-
-	// for y := start_y; y <= end_y; y++ {
-	// 	hash := node_hash(start_x, y)
-	// 	total_cost += grid.nodes[hash].cost
-	// }
-	// for x := start_x; x <= end_x; x++ {
-	// 	hash := node_hash(x, end_y)
-	// 	total_cost += grid.nodes[hash].cost
-	// }
-
-	// return total_cost
-
-	// For all nodes in the square, find the minimal cost of an item.
+// The cheapest block in the rectangle between here and the end, times the
+// distance. The best route can leave the rectangle for cheaper blocks, so
+// this is not guaranteed to be admissible; the study mode checks it.
+func weighted_manhattan_heuristic(crucible Crucible, grid Grid) int {
 	min_cost := 1000000
 
-	for y := start_y; y <= end_y; y++ {
-		for x := start_x; x <= end_x; x++ {
-			hash := node_hash(x, y)
-			if grid.nodes[hash].cost < min_cost {
-				min_cost = grid.nodes[hash].cost
-			}
+	for y := crucible.y; y < grid.height; y++ {
+		for x := crucible.x; x < grid.width; x++ {
+			min_cost = min(min_cost, grid.costs[y][x])
 		}
 	}
 
-	// return min_cost * (end_x - start_x + end_y - start_y)
-	return 0
+	return min_cost * distance_heuristic(crucible, grid)
 }
 
-// Try with some crazy pathing stuff...
-func in_line_heuristic(node Node, prior_node Node, grid Grid) int {
-	// If we have three in a row, the cost is massive.
-	path := make([][]int, 0)
+// The cost to the end ignoring the turning rules, found by one Dijkstra
+// run backwards from the end over plain positions. Relaxing the rules can
+// only make routes cheaper, so this is admissible, and it is much tighter
+// than the manhattan distance.
+func reverse_dijkstra_heuristic(grid Grid) func(Crucible, Grid) int {
+	end := [2]int{grid.width - 1, grid.height - 1}
 
-	path = append(path, node.position)
+	result := search.Search(search.Problem[[2]int]{
+		Starts: [][2]int{end},
+		Neighbours: func(position [2]int) []search.Edge[[2]int] {
+			edges := make([]search.Edge[[2]int], 0, 4)
 
-	current_node := prior_node
+			for _, step := range directions {
+				x, y := position[0]+step[0], position[1]+step[1]
 
-	counter := 0
+				if x < 0 || y < 0 || x >= grid.width || y >= grid.height {
+					continue
+				}
 
-	trace_length := 400
+				// Going forwards from (x, y) means paying for this block.
+				edges = append(edges, search.Edge[[2]int]{To: [2]int{x, y}, Cost: grid.costs[position[1]][position[0]]})
+			}
 
-	for current_node.best_connection != "" && counter <= trace_length {
-		path = append(path, current_node.position)
-		current_node = grid.nodes[current_node.best_connection]
-		counter += 1
-	}
+			return edges
+		},
+	})
 
-	if counter <= trace_length {
-		// If we have less than three in a row, the cost is simple.
-		if DEBUG {
-			fmt.Println("Returning reasonable cost for path: ", path)
-		}
-		return node.cost + prior_node.g
+	return func(crucible Crucible, grid Grid) int {
+		cost, _ := result.CostTo([2]int{crucible.x, crucible.y})
+		return cost
 	}
+}
 
-	x := path[0][0]
-	y := path[0][1]
+var heuristics = map[string]func(Crucible, Grid) int{
+	"manhattan": distance_heuristic,
+	"weighted":  weighted_manhattan_heuristic,
+}
 
-	all_xs_equal := true
-	all_ys_equal := true
+// The exact cost from every state to the end, found by searching the
+// reversed state graph from every goal state at once.
+func exact_costs_to_go(grid Grid) map[Crucible]int {
+	reversed := make(map[Crucible][]search.Edge[Crucible])
+	goals := make([]Crucible, 0)
 
-	for _, position := range path {
-		if position[0] != x {
-			all_xs_equal = false
-		}
-		if position[1] != y {
-			all_ys_equal = false
-		}
-	}
+	to_visit := []Crucible{{0, 0, -1, 0}}
+	seen := map[Crucible]bool{to_visit[0]: true}
+
+	for len(to_visit) > 0 {
+		crucible := to_visit[len(to_visit)-1]
+		to_visit = to_visit[:len(to_visit)-1]
 
-	if all_xs_equal || all_ys_equal {
-		if DEBUG {
-			fmt.Println("Returning unreasonable cost for path: ", path)
+		if grid.is_goal(crucible) {
+			goals = append(goals, crucible)
 		}
 
-		return 1000000
-	} else {
-		if DEBUG {
-			fmt.Println("Returning reasonable cost for path: ", path)
+		for _, edge := range grid.neighbours(crucible) {
+			reversed[edge.To] = append(reversed[edge.To], search.Edge[Crucible]{To: crucible, Cost: edge.Cost})
+
+			if !seen[edge.To] {
+				seen[edge.To] = true
+				to_visit = append(to_visit, edge.To)
+			}
 		}
-		return node.cost + prior_node.g
 	}
 
-}
-
-func consider_adjacent_nodes(grid Grid, node Node, end Node) []string {
-	updated_nodes := make([]string, 0)
+	result := search.Search(search.Problem[Crucible]{
+		Starts:     goals,
+		Neighbours: func(crucible Crucible) []search.Edge[Crucible] { return reversed[crucible] },
+	})
 
-	for _, connection := range node.connections {
-		// Get node
-		new_node := grid.nodes[connection]
+	return result.Costs()
+}
 
-		if node.best_connection == connection {
-			// This is the node that got us here. We don't want to consider it.
-			continue
-		}
+// Runs the search once per heuristic and writes how much work each did,
+// whether each found the optimal cost, and on how many of the states it
+// looked at it overestimated the true remaining cost. Returns the optimal
+// cost, as found without a heuristic.
+func study_heuristics(ctx context.Context, output io.Writer, grid Grid) int {
+	names := []string{"none", "manhattan", "weighted", "reverse-dijkstra"}
+	candidates := map[string]func(Crucible, Grid) int{
+		"none":             nil,
+		"manhattan":        distance_heuristic,
+		"weighted":         weighted_manhattan_heuristic,
+		"reverse-dijkstra": nil,
+	}
 
-		if !new_node.visited {
-			// This guy is gonna get me whether it likes it or not.
-			new_node.g = in_line_heuristic(new_node, node, grid)
+	exact := exact_costs_to_go(grid)
 
-			if new_node.g == 1000000 {
-				// CANNOT VISIT
-				continue
-			}
+	fmt.Fprintf(output, "%-18s %8s %10s %10s %10s %12s %s\n", "heuristic", "cost", "expanded", "pushed", "stale", "time", "violations")
 
-			new_node.visited = true
-			new_node.best_connection = node_hash(node.position[0], node.position[1])
-			new_node.h = weighted_manhattan_heuristic(new_node, end, grid)
+	optimal := -1
 
-			grid.nodes[connection] = new_node
-			updated_nodes = append(updated_nodes, connection)
+	for _, name := range names {
+		begin := time.Now()
 
-			continue
+		// Building the reverse Dijkstra table is part of what that
+		// heuristic costs, so it is timed too.
+		heuristic := candidates[name]
+		if name == "reverse-dijkstra" {
+			heuristic = reverse_dijkstra_heuristic(grid)
 		}
 
-		// If not visited, calculate g-cost and h-cost
-		new_g_cost := in_line_heuristic(new_node, node, grid)
-		new_h_cost := weighted_manhattan_heuristic(new_node, end, grid)
+		result := traverse_graph(ctx, grid, heuristic)
+		elapsed := time.Since(begin)
 
-		if new_g_cost == 1000000 {
-			// CANNOT VISIT
-			continue
+		violations := 0
+		if heuristic != nil {
+			for crucible := range result.Costs() {
+				if remaining, ok := exact[crucible]; ok && heuristic(crucible, grid) > remaining {
+					violations++
+				}
+			}
 		}
 
-		if (new_g_cost + new_h_cost) > (new_node.g + new_node.h) {
-			// We lose this time boys, sorry.
-			continue
+		if optimal < 0 {
+			optimal = result.Cost
+			if result.Err != nil {
+				optimal = result.Bound
+			}
 		}
 
-		// I take over 😈
-		new_node.g = new_g_cost
-		new_node.h = new_h_cost
-		new_node.best_connection = node_hash(node.position[0], node.position[1])
+		verdict := ""
+		if !result.Found || result.Cost != optimal {
+			verdict = fmt.Sprintf(" (NOT OPTIMAL, expected %d)", optimal)
+		}
 
-		// Set visited
-		grid.nodes[connection] = new_node
-		updated_nodes = append(updated_nodes, connection)
+		fmt.Fprintf(output, "%-18s %8d %10d %10d %10d %12s %d%s\n", name, result.Cost, result.Stats.Expanded, result.Stats.Pushed, result.Stats.Stale, elapsed.Round(time.Microsecond), violations, verdict)
 	}
 
-	return updated_nodes
-}
-
-// We're gonna need a priority queue here
-type QueueItem struct {
-	hash  string
-	cost  int
-	index int
+	return optimal
 }
 
-type NodeQueue []*QueueItem
+func visualize_grid(output io.Writer, grid Grid, path []Crucible) {
+	on_path := make(map[[2]int]bool)
+	for _, crucible := range path {
+		on_path[[2]int{crucible.x, crucible.y}] = true
+	}
 
-func (nq NodeQueue) Len() int {
-	return len(nq)
-}
+	for y := 0; y < grid.height; y++ {
+		for x := 0; x < grid.width; x++ {
+			if on_path[[2]int{x, y}] {
+				fmt.Fprint(output, color_yellow, grid.costs[y][x], color_none)
+			} else {
+				fmt.Fprint(output, color_green, grid.costs[y][x], color_none)
+			}
+		}
 
-func (nq NodeQueue) Less(i, j int) bool {
-	// This is the wrong way around on purpose. We want the items with the
-	// SMALLEST f-cost to be at the top of the queue.
-	return (nq[i].cost) < (nq[j].cost)
+		fmt.Fprintln(output)
+	}
 }
 
-func (nq NodeQueue) Swap(i, j int) {
-	nq[i], nq[j] = nq[j], nq[i]
-	nq[i].index = i
-	nq[j].index = j
-}
+func traverse_graph(ctx context.Context, grid Grid, heuristic func(Crucible, Grid) int) *search.Result[Crucible] {
+	problem := search.Problem[Crucible]{
+		Starts:     []Crucible{{0, 0, -1, 0}},
+		Neighbours: grid.neighbours,
+		IsGoal:     grid.is_goal,
+		Context:    ctx,
+	}
 
-func (nq *NodeQueue) Push(x any) {
-	item := x.(*QueueItem)
-	item.index = len(*nq)
-	*nq = append(*nq, item)
-	heap.Fix(nq, item.index)
-}
+	if heuristic != nil {
+		problem.Heuristic = func(crucible Crucible) int {
+			return heuristic(crucible, grid)
+		}
+	}
 
-func (nq *NodeQueue) Pop() any {
-	old := *nq
-	n := len(old)
-	item := old[n-1]
-	old[n-1] = nil
-	*nq = old[0 : n-1]
-	item.index = -1
-	return item
+	return search.Search(problem)
 }
 
-func (nq *NodeQueue) update(item *QueueItem) {
-	// In our implemetation, the node is modified already. We just need to put it in
-	// the right place in the queue.
-	heap.Fix(nq, item.index)
-}
+var min_run = flag.Int("min-run", 1, "blocks to move before turning (4 for part 2)")
+var max_run = flag.Int("max-run", 3, "most blocks to move without turning (10 for part 2)")
+var heuristic_name = flag.String("heuristic", "manhattan", "A* heuristic: manhattan, weighted, reverse-dijkstra or none")
+var study = flag.Bool("study", false, "compare every heuristic on stderr instead of solving once")
 
-func print_queue(nq NodeQueue) {
-	fmt.Println("Current queue state: ")
-	for _, item := range nq {
-		fmt.Print("{hash:", item.hash, " cost:", item.cost, "}, ")
-	}
-	fmt.Println()
+func main() {
+	aoc.Main(17, 1, solve)
 }
 
-func traverse_graph(grid Grid, start string, end string) int {
-	// Get start and end nodes
-	start_node := grid.nodes[start]
-	end_node := grid.nodes[end]
-
-	// Priority queue
-	to_process := make(NodeQueue, 1)
+func solve(run *aoc.Run) {
+	input := run.Input
 
-	// Add starting node to queue.
-	if DEBUG {
-		fmt.Println("Starting node: ", start_node.position)
-		fmt.Println("Ending node: ", end_node.position)
-	}
-	to_process[0] = &QueueItem{hash: node_hash(start_node.position[0], start_node.position[1]), cost: 0, index: 0}
-	if DEBUG {
-		fmt.Println("Initiating priority queue")
-	}
-	heap.Init(&to_process)
-	if DEBUG {
-		fmt.Println("Priority queue initiated")
-	}
+	all_rows := make([]string, 0)
 
-	// While there are nodes to process
-	for to_process.Len() > 0 {
-		// Get the node with the lowest f-cost
-		current_item := heap.Pop(&to_process).(*QueueItem)
-		current_node := grid.nodes[current_item.hash]
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
-		if DEBUG {
-			fmt.Println("Current node: ", current_node.position)
-		}
+		parsing.Debug("Given: ", text)
 
-		// // If we've reached the end, we're done.
-		// if slices.Equal(current_node.position, end_node.position) {
-		// 	break
-		// }
-
-		// Consider adjacent nodes
-		updated_nodes := consider_adjacent_nodes(grid, current_node, end_node)
-
-		// Add updated nodes to queue
-		for _, node := range updated_nodes {
-			to_update := grid.nodes[node]
-			to_process.Push(
-				&QueueItem{
-					hash: node_hash(to_update.position[0], to_update.position[1]),
-					cost: to_update.g + to_update.h,
-				},
-			)
+		width := -1
+		if len(all_rows) > 0 {
+			width = len(all_rows[0])
 		}
-	}
+		aoc.Check(input.Wrap(aoc.GridRow(text, "0123456789", width)))
 
-	if DEBUG {
-		fmt.Println("Finished processing")
-		print_queue(to_process)
+		all_rows = append(all_rows, text)
 	}
 
-	// Now we're done, we need to highlight the best path by walking backwards
-	// from the end node.
-	start_node_hash := node_hash(start_node.position[0], start_node.position[1])
-	current_node := grid.nodes[end]
+	aoc.Check(input.Err())
 
-	for node_hash(current_node.position[0], current_node.position[1]) != start_node_hash {
-		current_node.on_path = true
-		grid.nodes[node_hash(current_node.position[0], current_node.position[1])] = current_node
-		current_node = grid.nodes[current_node.best_connection]
+	if len(all_rows) == 0 {
+		aoc.Check(input.Missing("a grid of heat losses"))
 	}
 
-	// Do not highlight the start node
+	grid := rows_to_grid(all_rows, *min_run, *max_run)
+	run.Parsed()
 
-	total_cost := 0
-	starting_hash := node_hash(start_node.position[0], start_node.position[1])
-	for hash, node := range grid.nodes {
-		if node.on_path {
-			if starting_hash != hash {
-				total_cost += node.cost
-			}
-		}
+	// Part 2 is this file run with ultra crucibles.
+	if *min_run == 4 && *max_run == 10 {
+		run.Part = 2
 	}
 
-	return total_cost
-}
-
-func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	all_rows := make([]string, 0)
+	if *study {
+		run.Answer("Total cost", study_heuristics(run.Context(), os.Stderr, grid))
+		return
+	}
 
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
+	heuristic, ok := heuristics[*heuristic_name]
+	if *heuristic_name == "reverse-dijkstra" {
+		heuristic, ok = reverse_dijkstra_heuristic(grid), true
+	}
+	if !ok && *heuristic_name != "none" {
+		log.Fatal("Unknown heuristic: ", *heuristic_name)
+	}
 
-		if DEBUG {
-			fmt.Println("Given: ", text)
-		}
+	result := traverse_graph(run.Context(), grid, heuristic)
 
-		all_rows = append(all_rows, text)
+	if result.Err != nil {
+		searching.Infof("Gave up after expanding %d states: %v; the total cost is only a lower bound", result.Stats.Expanded, result.Err)
+		run.Answer("Total cost", result.Bound)
+		return
 	}
 
-	nodes := rows_to_nodes(all_rows)
-
-	cost := traverse_graph(nodes, node_hash(0, 0), node_hash(nodes.width-1, nodes.height-1))
+	if !result.Found {
+		log.Fatal("No route to the factory")
+	}
 
-	fmt.Println("Total cost: ", cost)
+	searching.Info("Expanded:", result.Stats.Expanded, "Pushed:", result.Stats.Pushed, "Stale:", result.Stats.Stale)
 
-	if DEBUG {
-		visualize_grid(nodes)
-		visualize_g(nodes)
-		visualize_connections(nodes)
+	if rendering.Enabled(aoc.Debug) {
+		done := run.Time(aoc.Render)
+		visualize_grid(rendering.Writer(aoc.Debug), grid, result.Path())
+		done()
 	}
+
+	run.Answer("Total cost", result.Cost)
 }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+func BenchmarkPart1(b *testing.B) {
+	// Only the example input is checked in for this day.
+	aoc.Benchmark(b, 17, 1, "test.txt", solve)
+}
@@ -0,0 +1,298 @@
+// Package lagoon solves the day 18 dig plan for either encoding. The area
+// is found from the trench's vertices alone (by the shoelace formula or by
+// coordinate compression), so it does not depend on how long the edges are.
+package lagoon
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"advent-of-code/aoc"
+)
+
+// How a plan line describes its step: Plain uses the direction letter and
+// count, Hex decodes them from the colour as in part 2.
+const (
+	Plain = "plain"
+	Hex   = "hex"
+)
+
+var directions = map[string][2]int{
+	"U": {0, -1},
+	"D": {0, 1},
+	"L": {-1, 0},
+	"R": {1, 0},
+}
+
+var new_directions = map[byte]string{
+	'0': "R",
+	'1': "D",
+	'2': "L",
+	'3': "U",
+}
+
+type Instruction struct {
+	direction [2]int
+	steps     int
+	color     string
+}
+
+var instruction_regex = regexp.MustCompile(`^([UDLR]) (\d+) \(#([0-9a-fA-F]+)\)$`)
+
+func ParseInstruction(str string, encoding string) (Instruction, error) {
+	matches := instruction_regex.FindStringSubmatchIndex(str)
+
+	if matches == nil {
+		return Instruction{}, aoc.Expected(1, "\"<U|D|L|R> <steps> (#<colour>)\"", aoc.Found(str))
+	}
+
+	color := str[matches[6]:matches[7]]
+
+	switch encoding {
+	case Plain:
+		steps, err := strconv.Atoi(str[matches[4]:matches[5]])
+		if err != nil {
+			return Instruction{}, aoc.Expected(matches[4]+1, "a step count that fits in an int", aoc.Found(str[matches[4]:matches[5]]))
+		}
+
+		return Instruction{directions[str[matches[2]:matches[3]]], steps, color}, nil
+	case Hex:
+		if len(color) != 6 {
+			return Instruction{}, aoc.Expected(matches[6]+1, "a colour of 6 hex digits", fmt.Sprintf("%d digits", len(color)))
+		}
+
+		// Unpack steps from the first five digits, direction from the last.
+		// Five hex digits always fit, and the regex only allows hex.
+		steps, _ := strconv.ParseInt(color[:5], 16, 64)
+
+		direction, ok := new_directions[color[5]]
+		if !ok {
+			return Instruction{}, aoc.Expected(matches[6]+6, "a direction digit from 0 to 3", aoc.Found(color[5:]))
+		}
+
+		return Instruction{directions[direction], int(steps), color}, nil
+	}
+
+	return Instruction{}, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+// The corners of the trench, starting and ending at the origin.
+func vertices(instructions []Instruction) [][2]int {
+	verticies := make([][2]int, len(instructions)+1)
+
+	for i, instruction := range instructions {
+		verticies[i+1] = [2]int{
+			verticies[i][0] + instruction.direction[0]*instruction.steps,
+			verticies[i][1] + instruction.direction[1]*instruction.steps,
+		}
+	}
+
+	return verticies
+}
+
+func trench_length(instructions []Instruction) int {
+	edges := 0
+
+	for _, instruction := range instructions {
+		edges += instruction.steps
+	}
+
+	return edges
+}
+
+// The shoelace formula gives the area enclosed by the path through the
+// centres of the trench cells; Pick's theorem then adds the half of each
+// trench cell that lies outside that path.
+func ShoelaceArea(instructions []Instruction) int {
+	verticies := vertices(instructions)
+
+	area := 0
+
+	for i := 0; i < len(verticies)-1; i++ {
+		area += verticies[i][0]*verticies[i+1][1] - verticies[i][1]*verticies[i+1][0]
+	}
+
+	area = area / 2
+
+	if area < 0 {
+		area = -area
+	}
+
+	return area + trench_length(instructions)/2 + 1
+}
+
+func unique_sorted(values []int) []int {
+	sort.Ints(values)
+
+	unique := values[:0]
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
+
+// Cell (x, y) covers [x, x+1) by [y, y+1). Cutting the plane at every
+// vertex coordinate v and v+1 leaves bands in which every cell is alike:
+// all trench, all inside or all outside. Flood fill the outside on the
+// (small) grid of bands and weigh what is left by band size.
+func CompressedArea(instructions []Instruction) int {
+	verticies := vertices(instructions)
+
+	xs := make([]int, 0, 2*len(verticies)+2)
+	ys := make([]int, 0, 2*len(verticies)+2)
+
+	for _, vertex := range verticies {
+		xs = append(xs, vertex[0], vertex[0]+1)
+		ys = append(ys, vertex[1], vertex[1]+1)
+	}
+
+	xs = unique_sorted(xs)
+	ys = unique_sorted(ys)
+
+	// A band of padding on every side so the outside is connected.
+	xs = append(append([]int{xs[0] - 1}, xs...), xs[len(xs)-1]+1)
+	ys = append(append([]int{ys[0] - 1}, ys...), ys[len(ys)-1]+1)
+
+	index := func(values []int, value int) int {
+		return sort.SearchInts(values, value)
+	}
+
+	n_x := len(xs) - 1
+	n_y := len(ys) - 1
+
+	trench := make([][]bool, n_y)
+	for j := range trench {
+		trench[j] = make([]bool, n_x)
+	}
+
+	for i := 0; i < len(verticies)-1; i++ {
+		from, to := verticies[i], verticies[i+1]
+
+		x_lo, x_hi := index(xs, min(from[0], to[0])), index(xs, max(from[0], to[0])+1)
+		y_lo, y_hi := index(ys, min(from[1], to[1])), index(ys, max(from[1], to[1])+1)
+
+		for j := y_lo; j < y_hi; j++ {
+			for k := x_lo; k < x_hi; k++ {
+				trench[j][k] = true
+			}
+		}
+	}
+
+	outside := make([][]bool, n_y)
+	for j := range outside {
+		outside[j] = make([]bool, n_x)
+	}
+
+	outside[0][0] = true
+	queue := [][2]int{{0, 0}}
+
+	for len(queue) > 0 {
+		j, k := queue[0][0], queue[0][1]
+		queue = queue[1:]
+
+		for _, step := range directions {
+			nj, nk := j+step[1], k+step[0]
+
+			if nj < 0 || nk < 0 || nj >= n_y || nk >= n_x || outside[nj][nk] || trench[nj][nk] {
+				continue
+			}
+
+			outside[nj][nk] = true
+			queue = append(queue, [2]int{nj, nk})
+		}
+	}
+
+	area := 0
+
+	for j := 0; j < n_y; j++ {
+		for k := 0; k < n_x; k++ {
+			if !outside[j][k] {
+				area += (ys[j+1] - ys[j]) * (xs[k+1] - xs[k])
+			}
+		}
+	}
+
+	return area
+}
+
+// Draws the trench in its own colours, with the dug out interior as '+',
+// unless it would take more than max_cells cells.
+func Render(w io.Writer, instructions []Instruction, max_cells int) error {
+	verticies := vertices(instructions)
+
+	min_x, max_x, min_y, max_y := 0, 0, 0, 0
+	for _, vertex := range verticies {
+		min_x, max_x = min(min_x, vertex[0]), max(max_x, vertex[0])
+		min_y, max_y = min(min_y, vertex[1]), max(max_y, vertex[1])
+	}
+
+	width := max_x - min_x + 1
+	height := max_y - min_y + 1
+
+	if width*height > max_cells {
+		return fmt.Errorf("plan is %d by %d, too big to render (limit %d cells)", width, height, max_cells)
+	}
+
+	grid := make([][]string, height)
+	// Whether the trench runs from each cell to the one below it.
+	down := make([][]bool, height)
+	for i := range grid {
+		grid[i] = make([]string, width)
+		down[i] = make([]bool, width)
+	}
+
+	x, y := -min_x, -min_y
+	for _, instruction := range instructions {
+		for i := 0; i < instruction.steps; i++ {
+			if instruction.direction[1] != 0 {
+				down[min(y, y+instruction.direction[1])][x] = true
+			}
+
+			x += instruction.direction[0]
+			y += instruction.direction[1]
+
+			grid[y][x] = instruction.color
+		}
+	}
+
+	for j, row := range grid {
+		inside := false
+
+		for i, cell := range row {
+			if cell == "" {
+				if inside {
+					fmt.Fprint(w, "+")
+				} else {
+					fmt.Fprint(w, ".")
+				}
+				continue
+			}
+
+			if len(cell) == 6 {
+				red, _ := strconv.ParseUint(cell[0:2], 16, 8)
+				green, _ := strconv.ParseUint(cell[2:4], 16, 8)
+				blue, _ := strconv.ParseUint(cell[4:6], 16, 8)
+				fmt.Fprintf(w, "\033[38;2;%d;%d;%dm#\033[0m", red, green, blue)
+			} else {
+				fmt.Fprint(w, "#")
+			}
+
+			// Crossing the trench where it heads downwards flips inside
+			// and outside; only counting those crossings means a U shaped
+			// dip along the row is not counted twice.
+			if down[j][i] {
+				inside = !inside
+			}
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
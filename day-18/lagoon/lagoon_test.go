@@ -0,0 +1,124 @@
+package lagoon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+var example = []string{
+	"R 6 (#70c710)",
+	"D 5 (#0dc571)",
+	"L 2 (#5713f0)",
+	"D 2 (#d2c081)",
+	"R 2 (#59c680)",
+	"D 2 (#411b91)",
+	"L 5 (#8ceee2)",
+	"U 2 (#caa173)",
+	"L 1 (#1b58a2)",
+	"U 2 (#caa171)",
+	"R 2 (#7807d2)",
+	"U 3 (#a77fa3)",
+	"L 2 (#015232)",
+	"U 2 (#7a21e3)",
+}
+
+func parse_plan(t *testing.T, lines []string, encoding string) []Instruction {
+	t.Helper()
+
+	instructions := make([]Instruction, len(lines))
+
+	for i, line := range lines {
+		instruction, err := ParseInstruction(line, encoding)
+		if err != nil {
+			t.Fatalf("ParseInstruction(%q, %s) failed: %v", line, encoding, err)
+		}
+		instructions[i] = instruction
+	}
+
+	return instructions
+}
+
+func TestArea(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		encoding string
+		want     int
+	}{
+		{"example", example, Plain, 62},
+		{"example decoded", example, Hex, 952408144115},
+		{"square", []string{"R 4 (#aaaaaa)", "U 4 (#aaaaaa)", "L 4 (#aaaaaa)", "D 4 (#aaaaaa)"}, Plain, 25},
+		// Clockwise and anticlockwise give the same area.
+		{"square backwards", []string{"U 4 (#aaaaaa)", "R 4 (#aaaaaa)", "D 4 (#aaaaaa)", "L 4 (#aaaaaa)"}, Plain, 25},
+		{"one cell wide", []string{"R 3 (#aaaaaa)", "L 3 (#aaaaaa)"}, Plain, 4},
+		{"plus, all trench", []string{
+			"R 1 (#aaaaaa)", "U 1 (#aaaaaa)", "R 1 (#aaaaaa)", "D 1 (#aaaaaa)",
+			"R 1 (#aaaaaa)", "D 1 (#aaaaaa)", "L 1 (#aaaaaa)", "D 1 (#aaaaaa)",
+			"L 1 (#aaaaaa)", "U 1 (#aaaaaa)", "L 1 (#aaaaaa)", "U 1 (#aaaaaa)",
+		}, Plain, 12},
+	}
+
+	for _, test := range tests {
+		instructions := parse_plan(t, test.lines, test.encoding)
+
+		if got := ShoelaceArea(instructions); got != test.want {
+			t.Errorf("%s: ShoelaceArea = %d, want %d", test.name, got, test.want)
+		}
+
+		if got := CompressedArea(instructions); got != test.want {
+			t.Errorf("%s: CompressedArea = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseInstructionErrors(t *testing.T) {
+	tests := []struct {
+		line     string
+		encoding string
+		column   int
+	}{
+		{"X 6 (#70c710)", Plain, 1},
+		{"R 6 #70c710", Plain, 1},
+		{"R 99999999999999999999 (#70c710)", Plain, 3},
+		{"R 4 (#aaaaaaa)", Hex, 7},
+		{"R 6 (#70c714)", Hex, 12},
+	}
+
+	for _, test := range tests {
+		_, err := ParseInstruction(test.line, test.encoding)
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) {
+			t.Errorf("ParseInstruction(%q, %s) = %v, want an input error", test.line, test.encoding, err)
+			continue
+		}
+
+		if input_err.Column != test.column {
+			t.Errorf("ParseInstruction(%q, %s) points at column %d, want %d", test.line, test.encoding, input_err.Column, test.column)
+		}
+	}
+
+	if _, err := ParseInstruction("R 6 (#70c710)", "octal"); err == nil {
+		t.Errorf("ParseInstruction accepted an unknown encoding")
+	}
+}
+
+func TestRender(t *testing.T) {
+	instructions := parse_plan(t, []string{"R 2 (#aaa)", "D 2 (#aaa)", "L 2 (#aaa)", "U 2 (#aaa)"}, Plain)
+
+	output := strings.Builder{}
+	if err := Render(&output, instructions, 100); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if want := "###\n#+#\n###\n"; output.String() != want {
+		t.Errorf("Render drew %q, want %q", output.String(), want)
+	}
+
+	if err := Render(&output, instructions, 8); err == nil {
+		t.Errorf("Render drew a 3 by 3 plan with a limit of 8 cells")
+	}
+}
@@ -1,153 +1,70 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
-	"os"
-	"regexp"
-	"strconv"
+	"flag"
+	"log"
 	"strings"
-)
-
-const DEBUG = true
-
-var directions = map[string][]int{
-	"U": {0, -1},
-	"D": {0, 1},
-	"L": {-1, 0},
-	"R": {1, 0},
-}
-
-var new_directions = map[string]string{
-	"0": "R",
-	"1": "D",
-	"2": "L",
-	"3": "U",
-}
 
-type Instruction struct {
-	direction []int
-	steps     int
-	color     string
-	vertex    []int
-}
-
-var instruction_regex = regexp.MustCompile(`^([UDLR]) (\d+) \(#([a-z0-9A-Z]*)\)`)
-
-func line_to_instruction(str string) Instruction {
-	matches := instruction_regex.FindStringSubmatch(str)
-
-	if matches == nil {
-		panic("No matches")
-	}
-
-	// direction := directions[matches[1]]
-	// steps, _ := strconv.Atoi(matches[2])
-	color := matches[3]
+	"advent-of-code/aoc"
+	"advent-of-code/day-18/lagoon"
+)
 
-	// Unpack steps
-	steps, _ := strconv.ParseInt(string(color[:len(color)-1]), 16, 32)
-	direction := directions[new_directions[string(color[len(color)-1])]]
+var parsing = aoc.Trace(aoc.Parse)
+var rendering = aoc.Trace(aoc.Render)
 
-	vertex := []int{0, 0}
+var encoding = flag.String("encoding", lagoon.Hex, "how to read each line: plain (part 1) or hex (part 2)")
+var method = flag.String("method", "shoelace", "how to find the area: shoelace or compress")
+var render_limit = flag.Int("render-limit", 20000, "only draw the trench when it covers at most this many cells")
 
-	return Instruction{direction, int(steps), color, vertex}
+func main() {
+	aoc.Main(18, 2, solve)
 }
 
-func dig_trenches(instructions []Instruction) {
-	// Actually figure out verticies
-	x := 0
-	y := 0
-
-	for i, instruction := range instructions {
-		x += instruction.direction[0] * instruction.steps
-		y += instruction.direction[1] * instruction.steps
-		instructions[i].vertex = []int{x, y}
+func solve(run *aoc.Run) {
+	if *encoding != lagoon.Plain && *encoding != lagoon.Hex {
+		log.Fatal("Unknown encoding: ", *encoding)
 	}
-}
 
-func instructions_to_vertex(instructions []Instruction) [][]int {
-	verticies := make([][]int, len(instructions)+2)
-
-	verticies[0] = []int{0, 0}
-
-	for i, instruction := range instructions {
-		verticies[i+1] = instruction.vertex
+	// Either file answers either part: only the encoding differs.
+	run.Part = 1
+	if *encoding == lagoon.Hex {
+		run.Part = 2
 	}
 
-	verticies[len(verticies)-1] = verticies[0]
-
-	return verticies
-}
-
-func shoelace(instructions []Instruction) int {
-	verticies := instructions_to_vertex(instructions)
+	input := run.Input
 
-	area := 0
+	instructions := make([]lagoon.Instruction, 0)
 
-	for i := 0; i <= len(verticies)-2; i++ {
-		current_x := verticies[i][0]
-		current_y := verticies[i][1]
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
 
-		next_x := verticies[i+1][0]
-		next_y := verticies[i+1][1]
+		parsing.Debug("Given: ", text)
 
-		area += current_x*next_y - current_y*next_x
-	}
-
-	area = area / 2
-
-	if area < 0 {
-		area = -area
-	}
-
-	edges := 0
+		instruction, err := lagoon.ParseInstruction(text, *encoding)
+		aoc.Check(input.Wrap(err))
 
-	for _, instruction := range instructions {
-		edges += instruction.steps
+		instructions = append(instructions, instruction)
 	}
 
-	fmt.Println("Edges:", edges)
+	aoc.Check(input.Err())
+	run.Parsed()
 
-	return area + edges/2 + 1
-}
+	parsing.Debug(instructions)
 
-func main() {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	all_rows := make([]string, 0)
-
-	for scanner.Scan() {
-		text := strings.TrimSpace(scanner.Text())
-
-		if DEBUG {
-			fmt.Println("Given: ", text)
+	if rendering.Enabled(aoc.Debug) {
+		done := run.Time(aoc.Render)
+		if err := lagoon.Render(rendering.Writer(aoc.Debug), instructions, *render_limit); err != nil {
+			rendering.Debug("Not rendering:", err)
 		}
-
-		all_rows = append(all_rows, text)
+		done()
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error reading from stdin:", err)
+	switch *method {
+	case "shoelace":
+		run.Answer("Filled", lagoon.ShoelaceArea(instructions))
+	case "compress":
+		run.Answer("Filled", lagoon.CompressedArea(instructions))
+	default:
+		log.Fatal("Unknown method: ", *method)
 	}
-
-	instructions := make([]Instruction, len(all_rows))
-
-	for i, row := range all_rows {
-		instructions[i] = line_to_instruction(row)
-	}
-
-	if DEBUG {
-		fmt.Println(instructions)
-	}
-
-	dig_trenches(instructions)
-
-	if DEBUG {
-		for _, instruction := range instructions {
-			fmt.Println(instruction.vertex)
-		}
-	}
-
-	fmt.Println("Filled:", shoelace(instructions))
 }
@@ -0,0 +1,108 @@
+package main
+
+// Finds which accepted region from `part2.go -export` holds each part,
+// without walking the workflows again.
+//
+//	go run lookup.go -table regions.csv '{x=787,m=2655,a=1222,s=2876}'
+//	go run lookup.go -table regions.json < parts.txt
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"advent-of-code/aoc"
+	"advent-of-code/day-19/workflow"
+)
+
+func load_table(path string) []workflow.TableRow {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Could not open table: ", err)
+	}
+	defer file.Close()
+
+	var rows []workflow.TableRow
+
+	switch {
+	case strings.HasSuffix(path, ".json"):
+		rows, err = workflow.ReadJSON(file)
+	case strings.HasSuffix(path, ".csv"):
+		rows, err = workflow.ReadCSV(file)
+	default:
+		log.Fatal("Table must end in .csv or .json: ", path)
+	}
+
+	aoc.Check(aoc.InFile(err, path))
+
+	return rows
+}
+
+func describe(row workflow.TableRow) string {
+	categories := make([]string, 0, len(row.Ranges))
+	for category := range row.Ranges {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	ranges := make([]string, len(categories))
+	for i, category := range categories {
+		bounds := row.Ranges[category]
+		ranges[i] = fmt.Sprintf("%d<=%s<=%d", bounds[0], category, bounds[1])
+	}
+
+	return strings.Join(ranges, ", ")
+}
+
+func main() {
+	table_path := flag.String("table", "regions.csv", "table written by part2.go -export")
+	flag.Parse()
+
+	rows := load_table(*table_path)
+
+	parts := flag.Args()
+
+	if len(parts) > 0 {
+		for i, text := range parts {
+			part, err := workflow.ParsePart(text)
+			aoc.Check(aoc.InFile(err, fmt.Sprintf("argument %d", i+1)))
+
+			look_up(rows, text, part)
+		}
+
+		return
+	}
+
+	input := aoc.NewInput(os.Stdin, "<stdin>")
+
+	for input.Scan() {
+		text := strings.TrimSpace(input.Text())
+		if text == "" {
+			continue
+		}
+
+		part, err := workflow.ParsePart(input.Text())
+		aoc.Check(input.Wrap(err))
+
+		look_up(rows, text, part)
+	}
+
+	aoc.Check(input.Err())
+}
+
+func look_up(rows []workflow.TableRow, text string, part workflow.Part) {
+
+	row, found := workflow.Lookup(rows, part)
+
+	if !found {
+		fmt.Println(text, "is rejected: no accepted region holds it")
+		return
+	}
+
+	fmt.Println(text, "is accepted by region", row.ID)
+	fmt.Println("  because", describe(row))
+	fmt.Println("  via", strings.Join(row.Path, " -> "))
+}
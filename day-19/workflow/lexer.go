@@ -0,0 +1,170 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"advent-of-code/aoc"
+)
+
+type TokenKind int
+
+const (
+	END TokenKind = iota
+	NEWLINE
+	IDENT
+	NUMBER
+	COMPARISON
+	ASSIGN
+	COLON
+	COMMA
+	LBRACE
+	RBRACE
+)
+
+var token_names = map[TokenKind]string{
+	END:        "end of input",
+	NEWLINE:    "end of line",
+	IDENT:      "name",
+	NUMBER:     "number",
+	COMPARISON: "comparison",
+	ASSIGN:     "'='",
+	COLON:      "':'",
+	COMMA:      "','",
+	LBRACE:     "'{'",
+	RBRACE:     "'}'",
+}
+
+func (kind TokenKind) String() string {
+	return token_names[kind]
+}
+
+// Line and Column are 1-indexed; Column counts runes.
+type Token struct {
+	Kind   TokenKind
+	Text   string
+	Line   int
+	Column int
+}
+
+func (token Token) String() string {
+	if token.Kind == END || token.Kind == NEWLINE {
+		return token.Kind.String()
+	}
+
+	return fmt.Sprintf("%q", token.Text)
+}
+
+func syntax_error(line int, column int, expected string, found string) *aoc.InputError {
+	return &aoc.InputError{Line: line, Column: column, Expected: expected, Found: found}
+}
+
+// Fills in the text of the line an error is about, and turns its column
+// from runes (as the tokens count them) to bytes (as errors count them).
+func with_text(err error, input string) error {
+	var input_err *aoc.InputError
+
+	if !errors.As(err, &input_err) {
+		return err
+	}
+
+	lines := strings.Split(input, "\n")
+
+	if input_err.Line < 1 || input_err.Line > len(lines) {
+		return err
+	}
+
+	line := strings.TrimSuffix(lines[input_err.Line-1], "\r")
+	runes := []rune(line)
+
+	input_err.Text = line
+	if input_err.Column > 0 {
+		input_err.Column = len(string(runes[:min(input_err.Column-1, len(runes))])) + 1
+	}
+
+	return err
+}
+
+var comparisons = []string{"<=", ">=", "==", "<", ">"}
+
+// Splits the whole input into tokens. Blank lines come through as two
+// NEWLINE tokens in a row, which is how the parser finds the gap between
+// the workflows and the parts.
+func Tokenize(input string) ([]Token, error) {
+	tokens := make([]Token, 0)
+	runes := []rune(input)
+
+	line, column := 1, 1
+
+	for i := 0; i < len(runes); {
+		char := runes[i]
+		start := Token{Line: line, Column: column}
+
+		advance := func(n int) string {
+			text := string(runes[i : i+n])
+			i += n
+			column += n
+			return text
+		}
+
+		switch {
+		case char == '\n':
+			start.Kind, start.Text = NEWLINE, "\n"
+			tokens = append(tokens, start)
+			i++
+			line, column = line+1, 1
+			continue
+		case unicode.IsSpace(char):
+			advance(1)
+			continue
+		case unicode.IsLetter(char):
+			n := 0
+			for i+n < len(runes) && unicode.IsLetter(runes[i+n]) {
+				n++
+			}
+			start.Kind, start.Text = IDENT, advance(n)
+		case unicode.IsDigit(char):
+			n := 0
+			for i+n < len(runes) && unicode.IsDigit(runes[i+n]) {
+				n++
+			}
+			start.Kind, start.Text = NUMBER, advance(n)
+		case char == '{':
+			start.Kind, start.Text = LBRACE, advance(1)
+		case char == '}':
+			start.Kind, start.Text = RBRACE, advance(1)
+		case char == ',':
+			start.Kind, start.Text = COMMA, advance(1)
+		case char == ':':
+			start.Kind, start.Text = COLON, advance(1)
+		default:
+			matched := false
+
+			for _, comparison := range comparisons {
+				n := len(comparison)
+				if i+n <= len(runes) && string(runes[i:i+n]) == comparison {
+					start.Kind, start.Text = COMPARISON, advance(n)
+					matched = true
+					break
+				}
+			}
+
+			if !matched && char == '=' {
+				start.Kind, start.Text = ASSIGN, advance(1)
+				matched = true
+			}
+
+			if !matched {
+				return nil, syntax_error(line, column, "a name, number or one of {}:,=<>", aoc.Found(string(char)))
+			}
+		}
+
+		tokens = append(tokens, start)
+	}
+
+	tokens = append(tokens, Token{Kind: END, Line: line, Column: column})
+
+	return tokens, nil
+}
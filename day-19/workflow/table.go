@@ -0,0 +1,175 @@
+package workflow
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"advent-of-code/aoc"
+)
+
+// One accepted region, as written to and read from an exported table.
+type TableRow struct {
+	ID     int               `json:"id"`
+	Ranges map[string][2]int `json:"ranges"`
+	Path   []string          `json:"path"`
+}
+
+func (row TableRow) Contains(part Part) bool {
+	for category, bounds := range row.Ranges {
+		if rating := part[category]; rating < bounds[0] || rating > bounds[1] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func ToTable(outcomes []Outcome) []TableRow {
+	rows := make([]TableRow, len(outcomes))
+
+	for i, outcome := range outcomes {
+		rows[i] = TableRow{ID: i, Ranges: make(map[string][2]int), Path: outcome.Path}
+
+		for category, interval := range outcome.Region {
+			rows[i].Ranges[category] = [2]int{interval.Low, interval.High}
+		}
+	}
+
+	return rows
+}
+
+// The categories used by any row, sorted.
+func table_categories(rows []TableRow) []string {
+	seen := make(map[string]bool)
+
+	for _, row := range rows {
+		for category := range row.Ranges {
+			seen[category] = true
+		}
+	}
+
+	categories := make([]string, 0, len(seen))
+	for category := range seen {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	return categories
+}
+
+func WriteJSON(w io.Writer, rows []TableRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(rows)
+}
+
+// Writes a header of id, <category>_low, <category>_high ..., path, then one
+// row per region. The path steps are separated by spaces.
+func WriteCSV(w io.Writer, rows []TableRow) error {
+	writer := csv.NewWriter(w)
+	categories := table_categories(rows)
+
+	header := []string{"id"}
+	for _, category := range categories {
+		header = append(header, category+"_low", category+"_high")
+	}
+	header = append(header, "path")
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{strconv.Itoa(row.ID)}
+
+		for _, category := range categories {
+			bounds := row.Ranges[category]
+			record = append(record, strconv.Itoa(bounds[0]), strconv.Itoa(bounds[1]))
+		}
+
+		record = append(record, strings.Join(row.Path, " "))
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func ReadJSON(r io.Reader) ([]TableRow, error) {
+	rows := []TableRow{}
+
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("could not read table: %w", err)
+	}
+
+	return rows, nil
+}
+
+func ReadCSV(r io.Reader) ([]TableRow, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		var csv_err *csv.ParseError
+		if errors.As(err, &csv_err) {
+			return nil, &aoc.InputError{Line: csv_err.Line, Column: csv_err.Column, Expected: "a CSV record", Found: csv_err.Err.Error()}
+		}
+		return nil, fmt.Errorf("could not read table: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, &aoc.InputError{Line: 1, Expected: "a header", Found: "end of input"}
+	}
+
+	header := records[0]
+
+	if len(header) < 2 || header[0] != "id" || header[len(header)-1] != "path" || len(header)%2 != 0 {
+		return nil, &aoc.InputError{Line: 1, Expected: "a header \"id,<category>_low,<category>_high,...,path\"", Text: strings.Join(header, ",")}
+	}
+
+	rows := make([]TableRow, 0, len(records)-1)
+
+	for line, record := range records[1:] {
+		numbers := make([]int, len(record)-1)
+
+		for i, field := range record[:len(record)-1] {
+			if numbers[i], err = strconv.Atoi(field); err != nil {
+				text := strings.Join(record, ",")
+				column := len(strings.Join(record[:i], ",")) + min(i, 1) + 1
+				return nil, &aoc.InputError{Line: line + 2, Column: column, Expected: fmt.Sprintf("a number for %s", header[i]), Found: aoc.Found(field), Text: text}
+			}
+		}
+
+		row := TableRow{ID: numbers[0], Ranges: make(map[string][2]int), Path: strings.Fields(record[len(record)-1])}
+
+		for i := 1; i < len(numbers); i += 2 {
+			category := strings.TrimSuffix(header[i], "_low")
+			row.Ranges[category] = [2]int{numbers[i], numbers[i+1]}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// The accepted region holding the part, if any. Regions never overlap, so
+// there is at most one.
+func Lookup(rows []TableRow, part Part) (TableRow, bool) {
+	for _, row := range rows {
+		if row.Contains(part) {
+			return row, true
+		}
+	}
+
+	return TableRow{}, false
+}
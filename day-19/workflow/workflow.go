@@ -0,0 +1,633 @@
+// Package workflow parses the day 19 workflow language into one syntax tree
+// that both the per-part evaluator (part 1) and the range counter (part 2)
+// walk. Rules may compare with <, >, <=, >= or ==.
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"advent-of-code/aoc"
+)
+
+const (
+	ACCEPT = "A"
+	REJECT = "R"
+	START  = "in"
+)
+
+// The puzzle's categories, which are always present even if no rule or
+// part mentions them.
+var CATEGORIES = []string{"x", "m", "a", "s"}
+
+type Rule struct {
+	Category string
+	Op       string
+	Value    int
+	Target   string
+	// Where the rule, and its target, start.
+	Line         int
+	Column       int
+	TargetColumn int
+}
+
+type Workflow struct {
+	Name     string
+	Rules    []Rule
+	Fallback string
+	Line     int
+	// Where the fallback starts.
+	FallbackColumn int
+}
+
+// A part's rating in each category.
+type Part map[string]int
+
+type Program struct {
+	Workflows map[string]*Workflow
+	Parts     []Part
+	// The puzzle's categories plus any other mentioned by a rule or a part,
+	// sorted.
+	Categories []string
+
+	// Called with progress messages while walking, if set.
+	Trace func(format string, args ...any)
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() Token {
+	token := p.tokens[p.pos]
+	if token.Kind != END {
+		p.pos++
+	}
+	return token
+}
+
+func (p *parser) expect(kind TokenKind, expected string) (Token, error) {
+	token := p.next()
+
+	if token.Kind != kind {
+		return token, syntax_error(token.Line, token.Column, expected, token.String())
+	}
+
+	return token, nil
+}
+
+func (p *parser) number() (int, error) {
+	token, err := p.expect(NUMBER, "a number")
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(token.Text)
+	if err != nil {
+		return 0, syntax_error(token.Line, token.Column, "a number that fits in an int", token.String())
+	}
+
+	return value, nil
+}
+
+// name{cat<value:target,...,fallback}
+func (p *parser) workflow() (*Workflow, error) {
+	name, err := p.expect(IDENT, "a workflow name")
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &Workflow{Name: name.Text, Line: name.Line}
+
+	if _, err := p.expect(LBRACE, "'{'"); err != nil {
+		return nil, err
+	}
+
+	for {
+		first, err := p.expect(IDENT, "a category or a target workflow")
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek().Kind != COMPARISON {
+			// A bare name is the fallback and must come last.
+			workflow.Fallback = first.Text
+			workflow.FallbackColumn = first.Column
+			break
+		}
+
+		rule := Rule{Category: first.Text, Op: p.next().Text, Line: first.Line, Column: first.Column}
+
+		if rule.Value, err = p.number(); err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(COLON, "':'"); err != nil {
+			return nil, err
+		}
+
+		target, err := p.expect(IDENT, "a target workflow")
+		if err != nil {
+			return nil, err
+		}
+		rule.Target = target.Text
+		rule.TargetColumn = target.Column
+
+		workflow.Rules = append(workflow.Rules, rule)
+
+		if _, err := p.expect(COMMA, "','"); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.expect(RBRACE, "'}' after the fallback workflow"); err != nil {
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// {cat=value,...}
+func (p *parser) part() (Part, error) {
+	if _, err := p.expect(LBRACE, "'{'"); err != nil {
+		return nil, err
+	}
+
+	part := Part{}
+
+	for {
+		category, err := p.expect(IDENT, "a category")
+		if err != nil {
+			return nil, err
+		}
+
+		if _, seen := part[category.Text]; seen {
+			return nil, syntax_error(category.Line, category.Column, "each category once", category.String()+" again")
+		}
+
+		if _, err := p.expect(ASSIGN, "'='"); err != nil {
+			return nil, err
+		}
+
+		if part[category.Text], err = p.number(); err != nil {
+			return nil, err
+		}
+
+		if p.peek().Kind == RBRACE {
+			p.next()
+			return part, nil
+		}
+
+		if _, err := p.expect(COMMA, "',' or '}'"); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) end_of_line() error {
+	if kind := p.peek().Kind; kind == END {
+		return nil
+	}
+
+	_, err := p.expect(NEWLINE, "end of line")
+	return err
+}
+
+// Parses the workflows, a blank line, then the parts (which may be
+// missing). Checks that every workflow named as a target exists.
+func Parse(input string) (*Program, error) {
+	program, err := parse(input)
+	if err != nil {
+		return nil, with_text(err, input)
+	}
+
+	return program, nil
+}
+
+func parse(input string) (*Program, error) {
+	tokens, err := Tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	program := &Program{Workflows: make(map[string]*Workflow)}
+
+	for p.peek().Kind == NEWLINE {
+		p.next()
+	}
+
+	for p.peek().Kind != NEWLINE && p.peek().Kind != END {
+		workflow, err := p.workflow()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, seen := program.Workflows[workflow.Name]; seen {
+			return nil, syntax_error(workflow.Line, 1, "a new workflow name", fmt.Sprintf("%q again", workflow.Name))
+		}
+		program.Workflows[workflow.Name] = workflow
+
+		if err := p.end_of_line(); err != nil {
+			return nil, err
+		}
+	}
+
+	for p.peek().Kind == NEWLINE {
+		p.next()
+	}
+
+	for p.peek().Kind != END {
+		part, err := p.part()
+		if err != nil {
+			return nil, err
+		}
+		program.Parts = append(program.Parts, part)
+
+		if err := p.end_of_line(); err != nil {
+			return nil, err
+		}
+
+		for p.peek().Kind == NEWLINE {
+			p.next()
+		}
+	}
+
+	if err := program.check(); err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+func (program *Program) exists(name string) bool {
+	_, ok := program.Workflows[name]
+	return ok || name == ACCEPT || name == REJECT
+}
+
+func (program *Program) check() error {
+	if _, ok := program.Workflows[START]; !ok {
+		return syntax_error(1, 1, fmt.Sprintf("a workflow called %q", START), "none")
+	}
+
+	categories := make(map[string]bool)
+	for _, category := range CATEGORIES {
+		categories[category] = true
+	}
+
+	for _, workflow := range program.Workflows {
+		for _, rule := range workflow.Rules {
+			categories[rule.Category] = true
+
+			if !program.exists(rule.Target) {
+				return syntax_error(rule.Line, rule.TargetColumn, "a rule whose target workflow exists", fmt.Sprintf("%q", rule.Target))
+			}
+		}
+
+		if !program.exists(workflow.Fallback) {
+			return syntax_error(workflow.Line, workflow.FallbackColumn, "a fallback workflow that exists", fmt.Sprintf("%q", workflow.Fallback))
+		}
+	}
+
+	if err := program.check_loops(); err != nil {
+		return err
+	}
+
+	for _, part := range program.Parts {
+		for category := range part {
+			categories[category] = true
+		}
+	}
+
+	for category := range categories {
+		program.Categories = append(program.Categories, category)
+	}
+	sort.Strings(program.Categories)
+
+	return nil
+}
+
+// Finds a loop among the workflows, such as in{x<5:in,A}, which would send
+// parts round forever. Every target counts, even one whose rule can never
+// hold on the way round. Reports the rule or fallback that closes the loop.
+func (program *Program) check_loops() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	path := []string{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		workflow, ok := program.Workflows[name]
+		if !ok || state[name] == visited {
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		follow := func(target string, line int, column int) error {
+			if state[target] == visiting {
+				loop := append(slices.Clone(path[slices.Index(path, target):]), target)
+				return syntax_error(line, column, fmt.Sprintf("a target that does not lead back to %q", target), "a loop: "+strings.Join(loop, " -> "))
+			}
+
+			return visit(target)
+		}
+
+		for _, rule := range workflow.Rules {
+			if err := follow(rule.Target, rule.Line, rule.TargetColumn); err != nil {
+				return err
+			}
+		}
+
+		if err := follow(workflow.Fallback, workflow.Line, workflow.FallbackColumn); err != nil {
+			return err
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+
+		return nil
+	}
+
+	names := make([]string, 0, len(program.Workflows))
+	for name := range program.Workflows {
+		names = append(names, name)
+	}
+	// Start from "in" so the loop reported is one parts really reach, if
+	// there is one.
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == START) != (names[j] == START) {
+			return names[i] == START
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (program *Program) trace(format string, args ...any) {
+	if program.Trace != nil {
+		program.Trace(format, args...)
+	}
+}
+
+func (rule Rule) matches(value int) bool {
+	switch rule.Op {
+	case "<":
+		return value < rule.Value
+	case ">":
+		return value > rule.Value
+	case "<=":
+		return value <= rule.Value
+	case ">=":
+		return value >= rule.Value
+	case "==":
+		return value == rule.Value
+	}
+
+	panic("unknown comparison " + rule.Op)
+}
+
+func (rule Rule) String() string {
+	return fmt.Sprintf("%s%s%d:%s", rule.Category, rule.Op, rule.Value, rule.Target)
+}
+
+// Sends the part through the workflows from "in". Returns whether it was
+// accepted and the names of the workflows it passed through.
+func (program *Program) Walk(part Part) (bool, []string) {
+	line_name := START
+	path := []string{}
+
+	for {
+		path = append(path, line_name)
+		program.trace("Currently on line name: %s", line_name)
+
+		if line_name == ACCEPT {
+			return true, path
+		} else if line_name == REJECT {
+			return false, path
+		}
+
+		workflow := program.Workflows[line_name]
+		line_name = workflow.Fallback
+
+		for _, rule := range workflow.Rules {
+			program.trace("Checking %d %s %d", part[rule.Category], rule.Op, rule.Value)
+
+			if rule.matches(part[rule.Category]) {
+				line_name = rule.Target
+				break
+			}
+		}
+	}
+}
+
+// The sum of the part's ratings.
+func (part Part) Value() int {
+	total := 0
+
+	for _, rating := range part {
+		total += rating
+	}
+
+	return total
+}
+
+// An inclusive range of ratings.
+type Interval struct {
+	Low  int
+	High int
+}
+
+func (interval Interval) size() int {
+	return max(0, interval.High-interval.Low+1)
+}
+
+// Splits the interval into the values where the rule holds and those
+// where it does not. Either side may be empty; the false side of == can be
+// in two pieces.
+func (rule Rule) split(interval Interval) ([]Interval, []Interval) {
+	pieces := func(intervals ...Interval) []Interval {
+		result := []Interval{}
+		for _, interval := range intervals {
+			if interval.size() > 0 {
+				result = append(result, interval)
+			}
+		}
+		return result
+	}
+
+	lo, hi, v := interval.Low, interval.High, rule.Value
+
+	switch rule.Op {
+	case "<":
+		return pieces(Interval{lo, min(hi, v-1)}), pieces(Interval{max(lo, v), hi})
+	case "<=":
+		return pieces(Interval{lo, min(hi, v)}), pieces(Interval{max(lo, v+1), hi})
+	case ">":
+		return pieces(Interval{max(lo, v+1), hi}), pieces(Interval{lo, min(hi, v)})
+	case ">=":
+		return pieces(Interval{max(lo, v), hi}), pieces(Interval{lo, min(hi, v-1)})
+	case "==":
+		return pieces(Interval{max(lo, v), min(hi, v)}), pieces(Interval{lo, min(hi, v-1)}, Interval{max(lo, v+1), hi})
+	}
+
+	panic("unknown comparison " + rule.Op)
+}
+
+// A box of ratings: one interval per category.
+type Region map[string]Interval
+
+func (region Region) with(category string, interval Interval) Region {
+	copied := make(Region, len(region))
+	for key, value := range region {
+		copied[key] = value
+	}
+	copied[category] = interval
+	return copied
+}
+
+// How many distinct parts the region holds.
+func (region Region) Size() int {
+	combos := 1
+
+	for _, interval := range region {
+		combos *= interval.size()
+	}
+
+	return combos
+}
+
+// A region that ended up at A or R, with the steps that took it there:
+// "in:s<1351" for a rule that held, "px:!a<2006" for one that did not and
+// "rfg:else" for a fallback.
+type Outcome struct {
+	Region Region
+	Path   []string
+}
+
+// Pushes every part in the region through the workflows at once, splitting
+// it wherever a rule only holds for some of it. The accepted and rejected
+// regions are disjoint and together cover the starting region.
+func (program *Program) WalkConstraints(region Region) (accepted []Outcome, rejected []Outcome) {
+	var walk func(region Region, line_name string, path []string)
+	var walk_rules func(region Region, workflow *Workflow, rules []Rule, path []string)
+
+	step := func(path []string, next string) []string {
+		return append(path[:len(path):len(path)], next)
+	}
+
+	walk = func(region Region, line_name string, path []string) {
+		switch line_name {
+		case ACCEPT:
+			program.trace("Accepting %v", region)
+			accepted = append(accepted, Outcome{region, path})
+		case REJECT:
+			program.trace("Rejecting %v", region)
+			rejected = append(rejected, Outcome{region, path})
+		default:
+			program.trace("Currently on line name: %s", line_name)
+			workflow := program.Workflows[line_name]
+			walk_rules(region, workflow, workflow.Rules, path)
+		}
+	}
+
+	walk_rules = func(region Region, workflow *Workflow, rules []Rule, path []string) {
+		if len(rules) == 0 {
+			walk(region, workflow.Fallback, step(path, workflow.Name+":else"))
+			return
+		}
+
+		rule := rules[0]
+		condition := fmt.Sprintf("%s%s%d", rule.Category, rule.Op, rule.Value)
+		when_true, when_false := rule.split(region[rule.Category])
+
+		if len(when_true) > 0 && len(when_false) > 0 {
+			program.trace("Splitting! Test range: %v at %s", region, rule)
+		}
+
+		for _, interval := range when_true {
+			walk(region.with(rule.Category, interval), rule.Target, step(path, workflow.Name+":"+condition))
+		}
+
+		// Keep the 'false' range and continue
+		for _, interval := range when_false {
+			walk_rules(region.with(rule.Category, interval), workflow, rules[1:], step(path, workflow.Name+":!"+condition))
+		}
+	}
+
+	walk(region, START, []string{})
+
+	return accepted, rejected
+}
+
+// The region covering [low, high] in every category.
+func (program *Program) FullRegion(low int, high int) Region {
+	region := Region{}
+
+	for _, category := range program.Categories {
+		region[category] = Interval{low, high}
+	}
+
+	return region
+}
+
+// Parses a single part such as "{x=787,m=2655,a=1222,s=2876}". The part
+// may have come from anywhere, so errors only give the column; whoever read
+// it says which line it was on.
+func ParsePart(str string) (Part, error) {
+	part, err := parse_part(str)
+	if err != nil {
+		err = with_text(err, str)
+
+		var input_err *aoc.InputError
+		if errors.As(err, &input_err) {
+			input_err.Line = 0
+		}
+
+		return nil, err
+	}
+
+	return part, nil
+}
+
+func parse_part(str string) (Part, error) {
+	tokens, err := Tokenize(str)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	part, err := p.part()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.end_of_line(); err != nil {
+		return nil, err
+	}
+
+	return part, nil
+}
@@ -0,0 +1,268 @@
+package workflow
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"advent-of-code/aoc"
+)
+
+const example = `px{a<2006:qkq,m>2090:A,rfg}
+pv{a>1716:R,A}
+lnx{m>1548:A,A}
+rfg{s<537:gd,x>2440:R,A}
+qs{s>3448:A,lnx}
+qkq{x<1416:A,crn}
+crn{x>2662:A,R}
+in{s<1351:px,qqz}
+qqz{s>2770:qs,m<1801:hdj,R}
+gd{a>3333:R,R}
+hdj{m>838:A,pv}
+
+{x=787,m=2655,a=1222,s=2876}
+{x=1679,m=44,a=2067,s=496}
+{x=2036,m=264,a=79,s=2244}
+{x=2461,m=1339,a=466,s=291}
+{x=2127,m=1623,a=2188,s=1013}
+`
+
+func parse_example(t *testing.T) *Program {
+	t.Helper()
+
+	program, err := Parse(example)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	return program
+}
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		accepted bool
+		path     string
+	}{
+		{true, "in qqz qs lnx A"},
+		{false, "in px rfg gd R"},
+		{true, "in qqz hdj pv A"},
+		{false, "in px qkq crn R"},
+		{true, "in px rfg A"},
+	}
+
+	program := parse_example(t)
+
+	if len(program.Parts) != len(tests) {
+		t.Fatalf("Parse found %d parts, want %d", len(program.Parts), len(tests))
+	}
+
+	total := 0
+
+	for i, test := range tests {
+		accepted, path := program.Walk(program.Parts[i])
+
+		if accepted != test.accepted || strings.Join(path, " ") != test.path {
+			t.Errorf("part %d: Walk = %v via %v, want %v via %s", i+1, accepted, path, test.accepted, test.path)
+		}
+
+		if accepted {
+			total += program.Parts[i].Value()
+		}
+	}
+
+	if total != 19114 {
+		t.Errorf("accepted parts add up to %d, want 19114", total)
+	}
+}
+
+func TestWalkConstraints(t *testing.T) {
+	program := parse_example(t)
+
+	accepted, rejected := program.WalkConstraints(program.FullRegion(1, 4000))
+
+	count := func(outcomes []Outcome) int {
+		total := 0
+		for _, outcome := range outcomes {
+			total += outcome.Region.Size()
+		}
+		return total
+	}
+
+	if got := count(accepted); got != 167409079868000 {
+		t.Errorf("accepted regions hold %d parts, want 167409079868000", got)
+	}
+
+	if got := count(accepted) + count(rejected); got != 4000*4000*4000*4000 {
+		t.Errorf("accepted and rejected regions hold %d parts, want 4000^4", got)
+	}
+
+	// Every part goes the same way one at a time as in bulk.
+	rows := ToTable(accepted)
+	for i, part := range program.Parts {
+		walked, _ := program.Walk(part)
+		if _, found := Lookup(rows, part); found != walked {
+			t.Errorf("part %d: accepted one at a time is %v, in bulk %v", i+1, walked, found)
+		}
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	tests := []struct {
+		rule  string
+		value int
+		want  bool
+		// How many of the ratings 1 to 10 the rule holds for.
+		count int
+	}{
+		{"x<5", 4, true, 4},
+		{"x<5", 5, false, 4},
+		{"x<=5", 5, true, 5},
+		{"x>5", 5, false, 5},
+		{"x>=5", 5, true, 6},
+		{"x==5", 5, true, 1},
+		{"x==5", 6, false, 1},
+		{"x<1", 1, false, 0},
+		{"x>=1", 10, true, 10},
+	}
+
+	for _, test := range tests {
+		program, err := Parse("in{" + test.rule + ":A,R}\n")
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.rule, err)
+			continue
+		}
+
+		if accepted, _ := program.Walk(Part{"x": test.value}); accepted != test.want {
+			t.Errorf("%s with x=%d: Walk = %v, want %v", test.rule, test.value, accepted, test.want)
+		}
+
+		accepted, _ := program.WalkConstraints(Region{"x": {1, 10}})
+
+		count := 0
+		for _, outcome := range accepted {
+			count += outcome.Region.Size()
+		}
+
+		if count != test.count {
+			t.Errorf("%s on 1 to 10: %d accepted, want %d", test.rule, count, test.count)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+	}{
+		{"no in", "px{x<5:A,R}\n", 1, 1},
+		{"missing target", "in{x<5:px,R}\n", 1, 8},
+		{"missing fallback", "in{x<5:A,px}\n", 1, 10},
+		{"repeated name", "in{x<5:A,R}\nin{R}\n", 2, 1},
+		{"missing colon", "in{x<5 A,R}\n", 1, 8},
+		{"huge value", "in{x<99999999999999999999:A,R}\n", 1, 6},
+		{"repeated category", "in{A}\n\n{x=1,x=2}\n", 3, 6},
+		{"loop to itself", "in{x<5:in,A}\n", 1, 8},
+		{"loop through another", "in{x<5:px,A}\npx{m>3:R,in}\n", 2, 10},
+		// Loops count even where no part could go round.
+		{"loop never taken", "in{x<5:px,A}\npx{x>10:in,R}\n", 2, 9},
+		{"loop away from in", "in{A}\nab{x<5:cd,R}\ncd{ab}\n", 3, 4},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.input)
+
+		input_err := &aoc.InputError{}
+		if !errors.As(err, &input_err) {
+			t.Errorf("%s: Parse = %v, want an input error", test.name, err)
+			continue
+		}
+
+		if input_err.Line != test.line || input_err.Column != test.column {
+			t.Errorf("%s: Parse points at line %d, column %d, want line %d, column %d (%v)",
+				test.name, input_err.Line, input_err.Column, test.line, test.column, err)
+		}
+	}
+}
+
+func TestLoopError(t *testing.T) {
+	_, err := Parse("in{x<5:px,A}\npx{m>3:qq,R}\nqq{px}\n")
+
+	if err == nil || !strings.Contains(err.Error(), "px -> qq -> px") {
+		t.Errorf("Parse = %v, want it to name the loop px -> qq -> px", err)
+	}
+}
+
+func TestParsePart(t *testing.T) {
+	part, err := ParsePart("{x=787,m=2655,a=1222,s=2876}")
+	if err != nil {
+		t.Fatalf("ParsePart failed: %v", err)
+	}
+
+	if part["x"] != 787 || part["s"] != 2876 || part.Value() != 7540 {
+		t.Errorf("ParsePart = %v", part)
+	}
+
+	_, err = ParsePart("{x=787,m:2655}")
+
+	input_err := &aoc.InputError{}
+	if !errors.As(err, &input_err) || input_err.Line != 0 || input_err.Column != 9 {
+		t.Errorf("ParsePart = %v, want an error at column 9 on no line", err)
+	}
+}
+
+func TestTables(t *testing.T) {
+	program := parse_example(t)
+	accepted, _ := program.WalkConstraints(program.FullRegion(1, 4000))
+	rows := ToTable(accepted)
+
+	formats := []struct {
+		name  string
+		write func(*bytes.Buffer, []TableRow) error
+		read  func(*bytes.Buffer) ([]TableRow, error)
+	}{
+		{"JSON", func(w *bytes.Buffer, rows []TableRow) error { return WriteJSON(w, rows) }, func(r *bytes.Buffer) ([]TableRow, error) { return ReadJSON(r) }},
+		{"CSV", func(w *bytes.Buffer, rows []TableRow) error { return WriteCSV(w, rows) }, func(r *bytes.Buffer) ([]TableRow, error) { return ReadCSV(r) }},
+	}
+
+	for _, format := range formats {
+		buffer := bytes.Buffer{}
+
+		if err := format.write(&buffer, rows); err != nil {
+			t.Errorf("%s: write failed: %v", format.name, err)
+			continue
+		}
+
+		read, err := format.read(&buffer)
+		if err != nil {
+			t.Errorf("%s: read failed: %v", format.name, err)
+			continue
+		}
+
+		if len(read) != len(rows) {
+			t.Errorf("%s: read %d rows, want %d", format.name, len(read), len(rows))
+			continue
+		}
+
+		for i := range rows {
+			same := read[i].ID == rows[i].ID && slices.Equal(read[i].Path, rows[i].Path) && len(read[i].Ranges) == len(rows[i].Ranges)
+			for category, bounds := range rows[i].Ranges {
+				same = same && read[i].Ranges[category] == bounds
+			}
+
+			if !same {
+				t.Errorf("%s: row %d read back as %+v, want %+v", format.name, i, read[i], rows[i])
+			}
+		}
+	}
+
+	_, err := ReadCSV(strings.NewReader("id,x_low,x_high,path\n0,1,ten,in:else\n"))
+
+	input_err := &aoc.InputError{}
+	if !errors.As(err, &input_err) || input_err.Line != 2 || input_err.Column != 5 {
+		t.Errorf("ReadCSV = %v, want an error at line 2, column 5", err)
+	}
+}
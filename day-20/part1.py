@@ -1,4 +1,7 @@
+import os
+import re
 import sys
+import time
 import attr
 
 input = sys.stdin.read().strip().split("\n")
@@ -18,6 +21,22 @@ QUEUE = []
 
 PART2 = True
 
+
+def parse_timeout(text):
+    """Seconds in a Go style duration such as 30s, 2m or 1h30m, as the
+    Go solvers take in -timeout and $AOC_TIMEOUT."""
+    units = {"ms": 0.001, "s": 1, "m": 60, "h": 3600}
+    parts = re.findall(r"(\d+(?:\.\d+)?)(ms|s|m|h)", text)
+
+    if not parts or "".join(n + u for n, u in parts) != text:
+        raise ValueError(f"bad AOC_TIMEOUT {text!r}")
+
+    return sum(float(n) * units[u] for n, u in parts)
+
+
+# Part 2 presses the button until rx fires, which can be forever.
+TIMEOUT = parse_timeout(os.environ["AOC_TIMEOUT"]) if os.environ.get("AOC_TIMEOUT") else None
+
 @attr.s
 class Conjunction:
     name: str = attr.ib()
@@ -126,8 +145,14 @@ if not PART2:
     print("total", PULSES[True] * PULSES[False])
 
 if PART2:
+    deadline = time.monotonic() + TIMEOUT if TIMEOUT else None
+
     i = 0
     while True:
+        if deadline and time.monotonic() > deadline:
+            print(f"rx has not fired after {i} pushes (partial: timed out)")
+            exit(1)
+
         i += 1
         print(f"push {i}")
 
@@ -0,0 +1,204 @@
+// Package search is a generic best-first search over any comparable state
+// type: Dijkstra when no heuristic is given, A* when one is. Stale queue
+// entries are skipped when popped (lazy deletion) rather than updated in
+// place.
+package search
+
+import (
+	"container/heap"
+	"context"
+)
+
+type Edge[S comparable] struct {
+	To   S
+	Cost int
+}
+
+type Problem[S comparable] struct {
+	Starts []S
+	// The states reachable in one step, with what each step costs. Costs
+	// must not be negative.
+	Neighbours func(state S) []Edge[S]
+	// If nil, the search runs until every reachable state is settled.
+	IsGoal func(state S) bool
+	// A lower bound on the cost from the state to a goal. If nil, zero is
+	// used and the search is plain Dijkstra.
+	Heuristic func(state S) int
+	// If given, the search gives up once it is done, and Result.Err says
+	// why.
+	Context context.Context
+}
+
+type Stats struct {
+	// States taken off the queue and expanded.
+	Expanded int
+	// Entries added to the queue, including the starts.
+	Pushed int
+	// Entries taken off the queue after a cheaper route had been found.
+	Stale int
+}
+
+type Result[S comparable] struct {
+	Found bool
+	Goal  S
+	Cost  int
+	Stats Stats
+	// Why the search stopped before finishing, such as
+	// context.DeadlineExceeded. Nil if it finished.
+	Err error
+	// If Err is set, the lowest priority still queued: with an admissible
+	// heuristic, no goal can cost less than this.
+	Bound int
+
+	costs   map[S]int
+	parents map[S]S
+}
+
+type item[S comparable] struct {
+	state S
+	cost  int
+	// cost plus heuristic; what the queue is ordered by.
+	priority int
+}
+
+type queue[S comparable] []item[S]
+
+func (q queue[S]) Len() int           { return len(q) }
+func (q queue[S]) Less(i, j int) bool { return q[i].priority < q[j].priority }
+func (q queue[S]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *queue[S]) Push(x any)        { *q = append(*q, x.(item[S])) }
+func (q *queue[S]) Pop() any {
+	old := *q
+	n := len(old)
+	popped := old[n-1]
+	*q = old[:n-1]
+	return popped
+}
+
+const context_check_interval = 1024
+
+// Runs the search. With a goal, it stops at the first goal state taken off
+// the queue, which is optimal as long as the heuristic never overestimates.
+func Search[S comparable](problem Problem[S]) *Result[S] {
+	result := &Result[S]{
+		costs:   make(map[S]int),
+		parents: make(map[S]S),
+	}
+
+	heuristic := problem.Heuristic
+	if heuristic == nil {
+		heuristic = func(S) int { return 0 }
+	}
+
+	to_process := &queue[S]{}
+	settled := make(map[S]bool)
+
+	push := func(state S, cost int) {
+		heap.Push(to_process, item[S]{state, cost, cost + heuristic(state)})
+		result.Stats.Pushed++
+	}
+
+	for _, start := range problem.Starts {
+		if _, seen := result.costs[start]; seen {
+			continue
+		}
+
+		result.costs[start] = 0
+		push(start, 0)
+	}
+
+	for to_process.Len() > 0 {
+		// Checking every time would cost more than some expansions do.
+		if problem.Context != nil && result.Stats.Expanded%context_check_interval == 0 {
+			if err := problem.Context.Err(); err != nil {
+				result.Err = err
+				result.Bound = (*to_process)[0].priority
+				return result
+			}
+		}
+
+		current := heap.Pop(to_process).(item[S])
+
+		if settled[current.state] || current.cost > result.costs[current.state] {
+			result.Stats.Stale++
+			continue
+		}
+
+		settled[current.state] = true
+
+		if problem.IsGoal != nil && problem.IsGoal(current.state) {
+			result.Found = true
+			result.Goal = current.state
+			result.Cost = current.cost
+			return result
+		}
+
+		result.Stats.Expanded++
+
+		for _, edge := range problem.Neighbours(current.state) {
+			// An inconsistent heuristic can settle a state before its
+			// cheapest route is found. Its cost and parent stay as they
+			// were used, so paths through it add up.
+			if settled[edge.To] {
+				continue
+			}
+
+			new_cost := current.cost + edge.Cost
+
+			if best, seen := result.costs[edge.To]; seen && best <= new_cost {
+				continue
+			}
+
+			result.costs[edge.To] = new_cost
+			result.parents[edge.To] = current.state
+			push(edge.To, new_cost)
+		}
+	}
+
+	return result
+}
+
+// The cheapest cost found to the state. Only final for states that were
+// settled, which is all reachable states when there is no goal.
+func (result *Result[S]) CostTo(state S) (int, bool) {
+	cost, ok := result.costs[state]
+	return cost, ok
+}
+
+// Every state reached, with its cost.
+func (result *Result[S]) Costs() map[S]int {
+	return result.costs
+}
+
+// The states from a start to the given state, both included.
+func (result *Result[S]) PathTo(state S) ([]S, bool) {
+	if _, ok := result.costs[state]; !ok {
+		return nil, false
+	}
+
+	path := []S{state}
+
+	for {
+		parent, ok := result.parents[path[len(path)-1]]
+		if !ok {
+			break
+		}
+		path = append(path, parent)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, true
+}
+
+// The path to the goal, if one was found.
+func (result *Result[S]) Path() []S {
+	if !result.Found {
+		return nil
+	}
+
+	path, _ := result.PathTo(result.Goal)
+	return path
+}
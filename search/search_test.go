@@ -0,0 +1,433 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// A small graph given as a list of edges.
+type graph map[string][]Edge[string]
+
+func (g graph) neighbours(state string) []Edge[string] {
+	return g[state]
+}
+
+// A grid of step costs, where moving onto a cell costs its digit and '#'
+// is a wall.
+type grid []string
+
+type point [2]int
+
+func (g grid) neighbours(p point) []Edge[point] {
+	edges := []Edge[point]{}
+
+	for _, step := range []point{{0, 1}, {1, 0}, {0, -1}, {-1, 0}} {
+		next := point{p[0] + step[0], p[1] + step[1]}
+
+		if next[1] < 0 || next[1] >= len(g) || next[0] < 0 || next[0] >= len(g[next[1]]) {
+			continue
+		}
+
+		if cell := g[next[1]][next[0]]; cell != '#' {
+			edges = append(edges, Edge[point]{next, int(cell - '0')})
+		}
+	}
+
+	return edges
+}
+
+func (g grid) corner() point {
+	return point{len(g[0]) - 1, len(g) - 1}
+}
+
+func (g grid) manhattan(p point) int {
+	corner := g.corner()
+	return corner[0] - p[0] + corner[1] - p[1]
+}
+
+var grids = []struct {
+	name string
+	grid grid
+	cost int
+}{
+	{"open", grid{
+		"11111",
+		"11111",
+		"11111",
+	}, 6},
+	{"detour", grid{
+		"19111",
+		"19191",
+		"11191",
+	}, 10},
+	{"walls", grid{
+		"1#111",
+		"1#1#1",
+		"111#1",
+	}, 10},
+	{"weighted", grid{
+		"2413432311323",
+		"3215453535623",
+		"3255245654254",
+		"3446585845452",
+		"4546657867536",
+		"1438598798454",
+		"4457876987766",
+		"3637877979653",
+		"4654967986887",
+		"4564679986453",
+		"1224686865563",
+		"2546548887735",
+		"4322674655533",
+	}, 78},
+}
+
+func TestDijkstraAndAStarAgree(t *testing.T) {
+	for _, test := range grids {
+		goal := test.grid.corner()
+
+		problem := Problem[point]{
+			Starts:     []point{{0, 0}},
+			Neighbours: test.grid.neighbours,
+			IsGoal:     func(p point) bool { return p == goal },
+		}
+
+		dijkstra := Search(problem)
+
+		problem.Heuristic = test.grid.manhattan
+		a_star := Search(problem)
+
+		for name, result := range map[string]*Result[point]{"Dijkstra": dijkstra, "A*": a_star} {
+			if !result.Found || result.Cost != test.cost || result.Goal != goal {
+				t.Errorf("%s grid, %s: found %v at %v with cost %d, want %v with cost %d",
+					test.name, name, result.Found, result.Goal, result.Cost, goal, test.cost)
+			}
+
+			// The path's steps must add up to the cost.
+			path := result.Path()
+			total := 0
+			for i := 1; i < len(path); i++ {
+				total += int(test.grid[path[i][1]][path[i][0]] - '0')
+			}
+
+			if path[0] != (point{0, 0}) || path[len(path)-1] != goal || total != test.cost {
+				t.Errorf("%s grid, %s: path %v costs %d, want a path from the start to the goal costing %d",
+					test.name, name, path, total, test.cost)
+			}
+		}
+
+		if a_star.Stats.Expanded > dijkstra.Stats.Expanded {
+			t.Errorf("%s grid: A* expanded %d states, more than Dijkstra's %d", test.name, a_star.Stats.Expanded, dijkstra.Stats.Expanded)
+		}
+	}
+}
+
+func TestLazyDeletion(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph graph
+		goal  string
+		costs map[string]int
+		stats Stats
+	}{
+		{
+			// b is pushed at 10, then again at 2 through c; the first entry
+			// is stale when it comes off the queue.
+			name: "cheaper later",
+			graph: graph{
+				"a": {{"b", 10}, {"c", 1}},
+				"c": {{"b", 1}},
+			},
+			costs: map[string]int{"a": 0, "b": 2, "c": 1},
+			stats: Stats{Expanded: 3, Pushed: 4, Stale: 1},
+		},
+		{
+			// A dearer route to a state already queued is never pushed.
+			name: "dearer later",
+			graph: graph{
+				"a": {{"b", 1}, {"c", 1}},
+				"c": {{"b", 5}},
+			},
+			costs: map[string]int{"a": 0, "b": 1, "c": 1},
+			stats: Stats{Expanded: 3, Pushed: 3, Stale: 0},
+		},
+		{
+			name: "stale entries left at the goal",
+			graph: graph{
+				"a": {{"b", 10}, {"c", 1}, {"d", 20}},
+				"c": {{"b", 2}, {"d", 1}},
+			},
+			goal:  "d",
+			costs: map[string]int{"a": 0, "b": 3, "c": 1, "d": 2},
+			stats: Stats{Expanded: 2, Pushed: 6, Stale: 0},
+		},
+		{
+			name: "zero cost edges and loops",
+			graph: graph{
+				"a": {{"b", 0}, {"a", 0}},
+				"b": {{"a", 0}, {"c", 3}},
+				"c": {{"b", 3}},
+			},
+			costs: map[string]int{"a": 0, "b": 0, "c": 3},
+			stats: Stats{Expanded: 3, Pushed: 3, Stale: 0},
+		},
+	}
+
+	for _, test := range tests {
+		problem := Problem[string]{Starts: []string{"a"}, Neighbours: test.graph.neighbours}
+		if test.goal != "" {
+			problem.IsGoal = func(state string) bool { return state == test.goal }
+		}
+
+		result := Search(problem)
+
+		if result.Stats != test.stats {
+			t.Errorf("%s: stats %+v, want %+v", test.name, result.Stats, test.stats)
+		}
+
+		for state, want := range test.costs {
+			if cost, ok := result.CostTo(state); !ok || cost != want {
+				t.Errorf("%s: CostTo(%s) = %d, %v, want %d", test.name, state, cost, ok, want)
+			}
+		}
+
+		if len(result.Costs()) != len(test.costs) {
+			t.Errorf("%s: reached %v, want %v", test.name, result.Costs(), test.costs)
+		}
+	}
+}
+
+func TestInconsistentHeuristic(t *testing.T) {
+	// The heuristic never overestimates, but it drops by more than the
+	// step from b to a costs, so a is settled at 4 before the route
+	// through b, costing 2, is found.
+	g := graph{
+		"s": {{"a", 4}, {"b", 1}},
+		"b": {{"a", 1}},
+		"a": {{"goal", 4}},
+	}
+	heuristic := map[string]int{"b": 5}
+
+	result := Search(Problem[string]{
+		Starts:     []string{"s"},
+		Neighbours: g.neighbours,
+		IsGoal:     func(state string) bool { return state == "goal" },
+		Heuristic:  func(state string) int { return heuristic[state] },
+	})
+
+	if !result.Found || result.Cost != 8 {
+		t.Fatalf("found %v with cost %d, want found with cost 8", result.Found, result.Cost)
+	}
+
+	if path := result.Path(); !slices.Equal(path, []string{"s", "a", "goal"}) {
+		t.Errorf("Path() = %v, want the route that cost 8, [s a goal]", path)
+	}
+
+	if cost, _ := result.CostTo("a"); cost != 4 {
+		t.Errorf("CostTo(a) = %d, want the 4 it was settled at", cost)
+	}
+}
+
+func TestPathTo(t *testing.T) {
+	g := graph{
+		"a": {{"b", 1}, {"c", 4}},
+		"b": {{"c", 1}, {"d", 5}},
+		"c": {{"d", 1}},
+	}
+
+	result := Search(Problem[string]{Starts: []string{"a"}, Neighbours: g.neighbours})
+
+	tests := []struct {
+		state string
+		path  []string
+		ok    bool
+	}{
+		{"a", []string{"a"}, true},
+		{"b", []string{"a", "b"}, true},
+		{"d", []string{"a", "b", "c", "d"}, true},
+		{"e", nil, false},
+	}
+
+	for _, test := range tests {
+		path, ok := result.PathTo(test.state)
+
+		if ok != test.ok || !slices.Equal(path, test.path) {
+			t.Errorf("PathTo(%s) = %v, %v, want %v, %v", test.state, path, ok, test.path, test.ok)
+		}
+	}
+
+	// No goal was asked for, so there is no path to one.
+	if path := result.Path(); path != nil {
+		t.Errorf("Path() without a goal = %v, want nil", path)
+	}
+}
+
+func TestMultipleStarts(t *testing.T) {
+	g := graph{
+		"a": {{"m", 5}},
+		"b": {{"m", 2}},
+		"m": {{"goal", 1}},
+	}
+
+	tests := []struct {
+		starts []string
+		cost   int
+		path   []string
+		// How many entries were queued, if it does not depend on which of
+		// the starts, which all cost nothing, comes off the queue first.
+		pushed int
+	}{
+		{[]string{"a"}, 6, []string{"a", "m", "goal"}, 3},
+		{[]string{"a", "b"}, 3, []string{"b", "m", "goal"}, 0},
+		// A start repeated is only queued once.
+		{[]string{"b", "b"}, 3, []string{"b", "m", "goal"}, 3},
+		{[]string{"b", "a", "b"}, 3, []string{"b", "m", "goal"}, 0},
+		// A start that is a goal costs nothing.
+		{[]string{"a", "goal"}, 0, []string{"goal"}, 0},
+	}
+
+	for _, test := range tests {
+		result := Search(Problem[string]{
+			Starts:     test.starts,
+			Neighbours: g.neighbours,
+			IsGoal:     func(state string) bool { return state == "goal" },
+		})
+
+		if !result.Found || result.Cost != test.cost || !slices.Equal(result.Path(), test.path) {
+			t.Errorf("starts %v: found %v with cost %d via %v, want cost %d via %v",
+				test.starts, result.Found, result.Cost, result.Path(), test.cost, test.path)
+		}
+
+		if test.pushed > 0 && result.Stats.Pushed != test.pushed {
+			t.Errorf("starts %v: pushed %d, want %d", test.starts, result.Stats.Pushed, test.pushed)
+		}
+	}
+}
+
+func TestUnreachableGoal(t *testing.T) {
+	tests := []struct {
+		name   string
+		starts []string
+		graph  graph
+	}{
+		{"no way there", []string{"a"}, graph{"a": {{"b", 1}}, "b": {{"a", 1}}}},
+		{"only a way back", []string{"a"}, graph{"a": {{"b", 1}}, "goal": {{"a", 1}}}},
+		{"no starts", nil, graph{"a": {{"goal", 1}}}},
+	}
+
+	for _, test := range tests {
+		result := Search(Problem[string]{
+			Starts:     test.starts,
+			Neighbours: test.graph.neighbours,
+			IsGoal:     func(state string) bool { return state == "goal" },
+		})
+
+		if result.Found || result.Err != nil || result.Path() != nil {
+			t.Errorf("%s: found %v via %v with error %v, want nothing found and no error",
+				test.name, result.Found, result.Path(), result.Err)
+		}
+
+		if _, ok := result.CostTo("goal"); ok {
+			t.Errorf("%s: CostTo(goal) says it was reached", test.name)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	// Counts up forever, so only the context can stop it.
+	line := func(n int) []Edge[int] { return []Edge[int]{{n + 1, 1}} }
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		// Cancels the context once this many states have been expanded, if
+		// not zero.
+		cancel_at int
+		context   context.Context
+		// The most states it may expand after the context is done.
+		slack int
+	}{
+		{"already cancelled", 0, cancelled, 0},
+		{"cancelled during", 5000, nil, context_check_interval},
+	}
+
+	for _, test := range tests {
+		ctx := test.context
+		neighbours := line
+
+		if test.cancel_at > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(context.Background())
+			defer cancel()
+
+			neighbours = func(n int) []Edge[int] {
+				if n+1 == test.cancel_at {
+					cancel()
+				}
+				return line(n)
+			}
+		}
+
+		result := Search(Problem[int]{Starts: []int{0}, Neighbours: neighbours, Context: ctx})
+
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s: Err = %v, want context.Canceled", test.name, result.Err)
+		}
+
+		if result.Found {
+			t.Errorf("%s: found a goal when there is none", test.name)
+		}
+
+		if expanded := result.Stats.Expanded; expanded < test.cancel_at || expanded > test.cancel_at+test.slack {
+			t.Errorf("%s: expanded %d states, want %d to %d", test.name, expanded, test.cancel_at, test.cancel_at+test.slack)
+		}
+
+		// Only the next number is ever queued, and it costs as much as it is.
+		if result.Bound != result.Stats.Expanded {
+			t.Errorf("%s: Bound = %d after expanding %d states, want %d", test.name, result.Bound, result.Stats.Expanded, result.Stats.Expanded)
+		}
+	}
+
+	// Stopped partway across a grid, the bound is never more than the
+	// cheapest route really costs.
+	open := grid{}
+	for i := 0; i < 100; i++ {
+		open = append(open, strings.Repeat("1", 100))
+	}
+	goal := open.corner()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := Search(Problem[point]{
+		Starts: []point{{0, 0}},
+		Neighbours: func(p point) []Edge[point] {
+			cancel()
+			return open.neighbours(p)
+		},
+		IsGoal:  func(p point) bool { return p == goal },
+		Context: ctx,
+	})
+
+	if stopped.Err == nil || stopped.Bound <= 0 || stopped.Bound > 198 {
+		t.Errorf("stopped across a grid with error %v and Bound %d, want an error and 1 to 198", stopped.Err, stopped.Bound)
+	}
+
+	// A context that is never done does not stop the search.
+	g := graph{"a": {{"goal", 1}}}
+	result := Search(Problem[string]{
+		Starts:     []string{"a"},
+		Neighbours: g.neighbours,
+		IsGoal:     func(state string) bool { return state == "goal" },
+		Context:    context.Background(),
+	})
+
+	if !result.Found || result.Err != nil {
+		t.Errorf("with a background context: found %v with error %v, want found and no error", result.Found, result.Err)
+	}
+}